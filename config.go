@@ -0,0 +1,311 @@
+package snake
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+)
+
+// ConfigOptions 分层加载配置参数
+// 加载顺序：Defaults默认配置 > 当前配置文件 > 环境配置文件 > 环境变量，后加载的覆盖先加载的。
+type ConfigOptions struct {
+	Defaults    string // 默认配置文件路径，文件不存在时跳过
+	Environment string // 环境名称，例如 production 会加载 config.production.yml，文件不存在时跳过
+	ENVPrefix   string // 环境变量前缀，例如 DEDE 对应 DEDE_DB_HOST，为空时不读取环境变量
+}
+
+// ConfigReport 配置项来源，字段路径 => 来源
+// 来源格式："file:路径" 或 "env:变量名"
+type ConfigReport map[string]string
+
+// LoadConfig 分层加载配置文件，并返回每个配置项的来源
+// 例子：
+// opt := snake.ConfigOptions{Defaults: "conf/default.yml", Environment: "production", ENVPrefix: "DEDE"}
+// report, err := snake.FS("conf/site.yml").LoadConfig(&conf, opt)
+// report["DB.Host"]
+// 返回："env:DEDE_DB_HOST"
+func (sk *snakeFileSystem) LoadConfig(conf interface{}, opts ...ConfigOptions) (ConfigReport, error) {
+	opt := ConfigOptions{}
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+
+	v := reflect.ValueOf(conf)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return nil, errors.New("config should be a pointer to struct")
+	}
+
+	report := ConfigReport{}
+
+	files := []string{}
+	if opt.Defaults != "" && FS(opt.Defaults).IsFile() {
+		files = append(files, FS(opt.Defaults).Get())
+	}
+	if !sk.IsFile() {
+		return nil, fmt.Errorf("config file %v not found", sk.Path)
+	}
+	files = append(files, sk.Get())
+	if opt.Environment != "" {
+		if env := configEnvFile(sk.Path, opt.Environment); FS(env).IsFile() {
+			files = append(files, env)
+		}
+	}
+
+	for _, file := range files {
+		// 按该层文件中出现的键判断设置了哪些配置项，显式设置的零值同样计入 ...
+		keys, err := decodeConfigKeys(file)
+		if err != nil {
+			return nil, err
+		}
+		format, _ := configFormat(file)
+		for _, path := range configFields(v.Elem().Type(), keys, format, "") {
+			report[path] = "file:" + file
+		}
+
+		if err := decodeConfig(file, conf); err != nil {
+			return nil, err
+		}
+	}
+
+	if opt.ENVPrefix != "" {
+		if err := configEnv(v.Elem(), []string{opt.ENVPrefix}, "", report); err != nil {
+			return nil, err
+		}
+	}
+
+	return report, nil
+}
+
+// SaveConfig 将配置写回文件
+// 根据扩展名选择 YAML(.yml/.yaml)、JSON(.json) 或 TOML(.toml) 格式，
+// 通过 AtomicWriter 写入，避免写入中断损坏原文件。
+func (sk *snakeFileSystem) SaveConfig(conf interface{}) error {
+	data, err := encodeConfig(sk.Path, conf)
+	if err != nil {
+		return err
+	}
+	return sk.AtomicWriter(data)
+}
+
+// configFormat 根据扩展名获取配置文件格式
+func configFormat(file string) (string, error) {
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".yml", ".yaml":
+		return "yaml", nil
+	case ".json":
+		return "json", nil
+	case ".toml":
+		return "toml", nil
+	}
+	return "", fmt.Errorf("unsupported config format: %v", file)
+}
+
+// configEnvFile 获取环境配置文件路径，与configor规则一致：config.yml => config.production.yml
+func configEnvFile(file, env string) string {
+	ext := filepath.Ext(file)
+	return strings.TrimSuffix(file, ext) + "." + env + ext
+}
+
+// decodeConfig 根据扩展名解析配置文件
+func decodeConfig(file string, conf interface{}) error {
+	format, err := configFormat(file)
+	if err != nil {
+		return err
+	}
+
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case "yaml":
+		return yaml.Unmarshal(data, conf)
+	case "json":
+		return json.Unmarshal(data, conf)
+	default:
+		_, err := toml.Decode(string(data), conf)
+		return err
+	}
+}
+
+// encodeConfig 根据扩展名生成配置文件内容
+func encodeConfig(file string, conf interface{}) ([]byte, error) {
+	format, err := configFormat(file)
+	if err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case "yaml":
+		return yaml.Marshal(conf)
+	case "json":
+		data, err := json.MarshalIndent(conf, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(data, '\n'), nil
+	default:
+		var buf bytes.Buffer
+		if err := toml.NewEncoder(&buf).Encode(conf); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+}
+
+// decodeConfigKeys 将配置文件解析为map，用于判断文件中设置了哪些键
+func decodeConfigKeys(file string) (map[string]interface{}, error) {
+	format, err := configFormat(file)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	res := map[string]interface{}{}
+	switch format {
+	case "yaml":
+		var m map[interface{}]interface{}
+		if err := yaml.Unmarshal(data, &m); err != nil {
+			return nil, err
+		}
+		res = configStringKeys(m)
+	case "json":
+		err = json.Unmarshal(data, &res)
+	default:
+		_, err = toml.Decode(string(data), &res)
+	}
+	return res, err
+}
+
+// configStringKeys 将YAML解析出的map的键转换为字符串
+func configStringKeys(m map[interface{}]interface{}) map[string]interface{} {
+	res := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		if sub, ok := v.(map[interface{}]interface{}); ok {
+			v = configStringKeys(sub)
+		}
+		res[fmt.Sprint(k)] = v
+	}
+	return res
+}
+
+// configFields 返回结构体中在keys里设置了的字段路径，嵌套结构体以"."连接
+// 字段与键的对应规则与各格式的解析库一致：优先使用标签，YAML为小写的字段名，JSON、TOML不区分大小写。
+func configFields(t reflect.Type, keys map[string]interface{}, format, prefix string) []string {
+	var res []string
+	for i := 0; i < t.NumField(); i++ {
+		fieldStruct := t.Field(i)
+		if fieldStruct.PkgPath != "" {
+			continue
+		}
+		value, ok := configKey(keys, fieldStruct, format)
+		if !ok {
+			continue
+		}
+
+		path := fieldStruct.Name
+		if prefix != "" {
+			path = prefix + "." + path
+		}
+
+		if sub, isMap := value.(map[string]interface{}); isMap && fieldStruct.Type.Kind() == reflect.Struct {
+			res = append(res, configFields(fieldStruct.Type, sub, format, path)...)
+			continue
+		}
+		res = append(res, path)
+	}
+	return res
+}
+
+// configKey 获取字段在配置文件中对应的值
+func configKey(keys map[string]interface{}, field reflect.StructField, format string) (interface{}, bool) {
+	name := strings.Split(field.Tag.Get(format), ",")[0]
+	switch {
+	case name == "-":
+		return nil, false
+	case name != "":
+		v, ok := keys[name]
+		return v, ok
+	case format == "yaml":
+		v, ok := keys[strings.ToLower(field.Name)]
+		return v, ok
+	}
+
+	if v, ok := keys[field.Name]; ok {
+		return v, true
+	}
+	for k, v := range keys {
+		if strings.EqualFold(k, field.Name) {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// configEnv 读取环境变量覆盖配置，变量名与configor规则一致：前缀_字段_子字段，全部大写，
+// 也可通过 env 标签指定变量名。
+func configEnv(v reflect.Value, names []string, prefix string, report ConfigReport) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		fieldStruct := t.Field(i)
+		field := v.Field(i)
+		if fieldStruct.PkgPath != "" {
+			continue
+		}
+
+		path := fieldStruct.Name
+		if prefix != "" {
+			path = prefix + "." + path
+		}
+		fieldNames := append(append([]string{}, names...), fieldStruct.Name)
+
+		if field.Kind() == reflect.Struct {
+			if err := configEnv(field, fieldNames, path, report); err != nil {
+				return err
+			}
+			continue
+		}
+
+		env := fieldStruct.Tag.Get("env")
+		if env == "" {
+			env = strings.ToUpper(strings.Join(fieldNames, "_"))
+		}
+
+		value, ok := os.LookupEnv(env)
+		if !ok {
+			continue
+		}
+
+		switch field.Kind() {
+		case reflect.String:
+			field.SetString(value)
+		case reflect.Bool:
+			switch strings.ToLower(value) {
+			case "", "0", "f", "false":
+				field.SetBool(false)
+			default:
+				field.SetBool(true)
+			}
+		default:
+			if err := yaml.Unmarshal([]byte(value), field.Addr().Interface()); err != nil {
+				return fmt.Errorf("env %v: %v", env, err)
+			}
+		}
+		report[path] = "env:" + env
+	}
+	return nil
+}
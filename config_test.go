@@ -0,0 +1,58 @@
+package snake
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadConfigReportZeroValue(t *testing.T) {
+	type db struct {
+		Host string
+		Port int
+	}
+	type config struct {
+		Debug bool
+		Name  string `json:"site_name" toml:"site_name"`
+		DB    db
+	}
+
+	for _, c := range []struct {
+		ext, defaults, config string
+	}{
+		{".yml", "debug: true\nname: a\ndb:\n  host: h\n  port: 3306\n", "debug: false\ndb:\n  port: 0\n"},
+		{".json", `{"debug":true,"site_name":"a","db":{"host":"h","port":3306}}`, `{"Debug":false,"DB":{"Port":0}}`},
+		{".toml", "debug = true\nsite_name = \"a\"\n[db]\nhost = \"h\"\nport = 3306\n", "debug = false\n[db]\nport = 0\n"},
+	} {
+		dir := t.TempDir()
+		defaults := filepath.Join(dir, "defaults"+c.ext)
+		p := filepath.Join(dir, "config"+c.ext)
+		if err := os.WriteFile(defaults, []byte(c.defaults), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(c.config), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		var conf config
+		report, err := FS(p).LoadConfig(&conf, ConfigOptions{Defaults: defaults})
+		if err != nil {
+			t.Fatalf("%s: %v", c.ext, err)
+		}
+		if conf.Debug || conf.DB.Port != 0 || conf.Name != "a" || conf.DB.Host != "h" {
+			t.Errorf("%s: got %+v", c.ext, conf)
+		}
+
+		// 显式设置为零值的配置项归属于设置它的文件 ...
+		for path, file := range map[string]string{
+			"Debug":   p,
+			"DB.Port": p,
+			"Name":    defaults,
+			"DB.Host": defaults,
+		} {
+			if report[path] != "file:"+file {
+				t.Errorf("%s: report[%s] = %q, want file:%s", c.ext, path, report[path], file)
+			}
+		}
+	}
+}
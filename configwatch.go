@@ -0,0 +1,238 @@
+package snake
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jinzhu/configor"
+)
+
+// ConfigWatcher 配置文件热加载
+type ConfigWatcher struct {
+	path     string
+	conf     interface{}
+	value    atomic.Value
+	onChange func(conf interface{}, err error)
+	interval time.Duration
+	modTime  time.Time
+	size     int64
+	mu       sync.Mutex
+	confMu   sync.RWMutex
+	stop     chan struct{}
+	once     sync.Once
+}
+
+// WatchConfig 加载配置文件并在文件变化时自动重新加载
+// 新配置通过校验后在写锁内更新conf，并原子替换 Get() 返回的配置，
+// 直接读取conf时需持有 RLock()，或使用 Get() 返回的不可变副本。
+// 每次重新加载后调用onChange，传入当前生效的配置，校验失败时保留旧配置并返回错误。
+// 支持的校验标签：
+// required:"true"  必填
+// min:"1" max:"65535"  数值范围，字符串、数组为长度范围
+// enum:"mysql,sqlite"  枚举值
+// 例子：
+// w, err := snake.FS("conf/site.yml").WatchConfig(&conf, func(c interface{}, err error) { ... })
+// defer w.Stop()
+// w.RLock()
+// port := conf.Port
+// w.RUnlock()
+func (sk *snakeFileSystem) WatchConfig(conf interface{}, onChange func(conf interface{}, err error), interval ...time.Duration) (*ConfigWatcher, error) {
+	v := reflect.ValueOf(conf)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return nil, errors.New("config should be a pointer to struct")
+	}
+
+	w := &ConfigWatcher{
+		path:     sk.Get(),
+		conf:     conf,
+		onChange: onChange,
+		interval: time.Second,
+		stop:     make(chan struct{}),
+	}
+	if len(interval) > 0 && interval[0] > 0 {
+		w.interval = interval[0]
+	}
+
+	if err := w.reload(); err != nil {
+		return nil, err
+	}
+
+	go w.watch()
+	return w, nil
+}
+
+// Get 返回当前配置的指针，每次重新加载都会替换为新的副本，读取到的配置不会再被修改
+func (w *ConfigWatcher) Get() interface{} {
+	return w.value.Load()
+}
+
+// RLock 锁定conf以读取，重新加载时会等待读取完成再更新conf
+func (w *ConfigWatcher) RLock() {
+	w.confMu.RLock()
+}
+
+// RUnlock 释放 RLock() 获取的读锁
+func (w *ConfigWatcher) RUnlock() {
+	w.confMu.RUnlock()
+}
+
+// Reload 立即重新加载配置文件
+func (w *ConfigWatcher) Reload() error {
+	err := w.reload()
+	if w.onChange != nil {
+		w.onChange(w.Get(), err)
+	}
+	return err
+}
+
+// Stop 停止监听配置文件
+func (w *ConfigWatcher) Stop() {
+	w.once.Do(func() {
+		close(w.stop)
+	})
+}
+
+// watch 定时检查配置文件修改时间及大小
+func (w *ConfigWatcher) watch() {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			info, err := os.Stat(w.path)
+			if err != nil {
+				continue
+			}
+			w.mu.Lock()
+			changed := !info.ModTime().Equal(w.modTime) || info.Size() != w.size
+			w.mu.Unlock()
+			if changed {
+				w.Reload()
+			}
+		}
+	}
+}
+
+// reload 加载并校验配置，通过后更新conf并原子替换 Get() 返回的配置
+func (w *ConfigWatcher) reload() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	info, err := os.Stat(w.path)
+	if err != nil {
+		return err
+	}
+
+	// 无论成功与否均记录本次文件状态，避免反复加载同一个错误的文件 ...
+	w.modTime = info.ModTime()
+	w.size = info.Size()
+
+	next := reflect.New(reflect.TypeOf(w.conf).Elem())
+	if err := configor.Load(next.Interface(), w.path); err != nil {
+		return err
+	}
+	if err := ValidateConfig(next.Interface()); err != nil {
+		return err
+	}
+
+	w.confMu.Lock()
+	reflect.ValueOf(w.conf).Elem().Set(next.Elem())
+	w.confMu.Unlock()
+
+	w.value.Store(next.Interface())
+	return nil
+}
+
+// ValidateConfig 根据结构体标签校验配置
+func ValidateConfig(conf interface{}) error {
+	v := reflect.Indirect(reflect.ValueOf(conf))
+	if v.Kind() != reflect.Struct {
+		return errors.New("config should be a struct")
+	}
+	return validateStruct(v, "")
+}
+
+// validateStruct 递归校验结构体字段
+func validateStruct(v reflect.Value, prefix string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		fieldStruct := t.Field(i)
+		field := v.Field(i)
+		if fieldStruct.PkgPath != "" {
+			continue
+		}
+
+		path := fieldStruct.Name
+		if prefix != "" {
+			path = prefix + "." + path
+		}
+
+		if fieldStruct.Tag.Get("required") == "true" && field.IsZero() {
+			return fmt.Errorf("%v is required, but blank", path)
+		}
+
+		if field.Kind() == reflect.Struct {
+			if err := validateStruct(field, path); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := validateRange(field, path, fieldStruct.Tag); err != nil {
+			return err
+		}
+
+		if enum := fieldStruct.Tag.Get("enum"); enum != "" && !field.IsZero() {
+			value := fmt.Sprint(field.Interface())
+			if !String(value).ExistSlice(strings.Split(enum, ",")) {
+				return fmt.Errorf("%v must be one of [%v], got %v", path, enum, value)
+			}
+		}
+	}
+	return nil
+}
+
+// validateRange 校验min、max标签，数值比较大小，字符串、数组比较长度
+func validateRange(field reflect.Value, path string, tag reflect.StructTag) error {
+	var value float64
+	switch field.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		value = float64(field.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		value = float64(field.Uint())
+	case reflect.Float32, reflect.Float64:
+		value = field.Float()
+	case reflect.String:
+		value = float64(Len(field.String()))
+	case reflect.Slice, reflect.Map, reflect.Array:
+		value = float64(field.Len())
+	default:
+		return nil
+	}
+
+	if min := tag.Get("min"); min != "" {
+		if n, err := strconv.ParseFloat(min, 64); err != nil {
+			return fmt.Errorf("%v: invalid min tag %q", path, min)
+		} else if value < n {
+			return fmt.Errorf("%v must be >= %v, got %v", path, min, value)
+		}
+	}
+	if max := tag.Get("max"); max != "" {
+		if n, err := strconv.ParseFloat(max, 64); err != nil {
+			return fmt.Errorf("%v: invalid max tag %q", path, max)
+		} else if value > n {
+			return fmt.Errorf("%v must be <= %v, got %v", path, max, value)
+		}
+	}
+	return nil
+}
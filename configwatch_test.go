@@ -0,0 +1,71 @@
+package snake
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatchConfigReload(t *testing.T) {
+	type config struct {
+		Port int `min:"1"`
+	}
+	p := filepath.Join(t.TempDir(), "site.yml")
+	if err := os.WriteFile(p, []byte("port: 80\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var conf config
+	changes := make(chan *config, 2)
+	w, err := FS(p).WatchConfig(&conf, func(c interface{}, err error) {
+		if err != nil {
+			changes <- nil
+			return
+		}
+		changes <- c.(*config)
+	}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+
+	// 其他协程读取conf的同时重新加载 ...
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			w.RLock()
+			_ = conf.Port
+			w.RUnlock()
+		}
+	}()
+
+	if err := os.WriteFile(p, []byte("port: 8080\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	<-done
+	if c := <-changes; c == nil || c.Port != 8080 {
+		t.Errorf("onChange: got %+v, want Port 8080", c)
+	}
+	w.RLock()
+	if conf.Port != 8080 {
+		t.Errorf("conf.Port: got %d, want 8080", conf.Port)
+	}
+	w.RUnlock()
+
+	// 校验失败时保留旧配置 ...
+	if err := os.WriteFile(p, []byte("port: 0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Reload(); err == nil {
+		t.Fatal("Reload with invalid port: got nil error")
+	}
+	<-changes
+	if got := w.Get().(*config).Port; got != 8080 || conf.Port != 8080 {
+		t.Errorf("after invalid reload: Get().Port = %d, conf.Port = %d, want 8080", got, conf.Port)
+	}
+}
@@ -0,0 +1,418 @@
+package snake
+
+import (
+	"bytes"
+	"mime"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+)
+
+// CharsetCandidate 编码检测结果
+type CharsetCandidate struct {
+	Charset    string  // 编码名称，大写，例如：UTF-8、GBK、SHIFT_JIS
+	Confidence float64 // 可信度，0到1
+	BOM        bool    // 是否带BOM
+}
+
+// DetectOptions 编码检测参数
+type DetectOptions struct {
+	Language    string // 语言提示，例如：zh-CN、zh-TW、ja、ko、ru
+	ContentType string // HTTP Content-Type头，其中的charset作为提示，例如：text/html; charset=gbk
+	Partial     bool   // 内容为截取的开头部分，忽略末尾不完整的字符
+}
+
+// charsetBOMs 带BOM的编码，UTF-32LE 需在 UTF-16LE 之前判断
+var charsetBOMs = []struct {
+	charset string
+	bom     []byte
+}{
+	{"UTF-32LE", []byte{0xff, 0xfe, 0x00, 0x00}},
+	{"UTF-32BE", []byte{0x00, 0x00, 0xfe, 0xff}},
+	{"UTF-8", []byte{0xef, 0xbb, 0xbf}},
+	{"UTF-16LE", []byte{0xff, 0xfe}},
+	{"UTF-16BE", []byte{0xfe, 0xff}},
+}
+
+// charsetDetector 多字节及单字节编码的检测规则
+type charsetDetector struct {
+	charset string
+	prior   float64                        // 先验权重，单字节编码任意内容均可解码，权重较低
+	langs   []string                       // 对应的语言
+	score   func(rs []rune, i int) float64 // 第i个非ASCII字符的合理程度，0到1
+}
+
+// 常用汉字，用于区分GBK与Big5解码结果 ...
+var (
+	commonHans     = runeSet("的一是不了人我在有他这中大来上个国到说们为子和你地出道也时年得就那要下以生会自着去之过家学对可她里后小么心多天而能好都然没日于起还发成事只作当想看文无开手十用主行方又如前所本见经头面公同三已老从动两长知民样现分将外但身些与高意进把法此实回二理美点月明其种声全工己话儿者向情部正名定女问力机给等几很业最间新什打便位因重被走电四第门相次东政海口使教西再平真听世气信北少关并内加化由却代军产入先山五太水万市眼体别处总才场师书比住员九笑性通目华报立马命张活难神数件安表原车白应路期叫死常提感金何更反合放做系计或司利受光王果亲界及今京务制解各任至清物台象记边共风战干接它许八特觉望直服毛林题建南度统色字请交爱让认算论百吃义科怎元社术结六功指思非流每青管夫连远资队跟带花快条院变联言权往展该领传近留红治决周保达办运武半候七必城父强步完革深区即求品士转量空甚众技轻程告江语英基派满式李息写呢识极令黄德收脸钱党倒未持取设始版双历越史商千片容研像找友孩站广改议形委早房音火际则首单据导影失拿网香似斯专石若兵弟谁校读志飞观争究包组造落视济喜离虽坏兴切系统内容管理网站模板文章栏目会员登录注册首页搜索评论标签图片下载信息发布时间作者来源更新织梦")
+	commonHantHans = runeSet("的一是不了人我在有他這中大來上個國到說們為子和你地出道也時年得就那要下以生會自著去之過家學對可她裡後小麼心多天而能好都然沒日於起還發成事只作當想看文無開手十用主行方又如前所本見經頭面公同三已老從動兩長知民樣現分將外但身些與高意進把法此實回二理美點月明其種聲全工己話兒者向情部正名定女問力機給等幾很業最間新什打便位因重被走電四第門相次東政海口使教西再平真聽世氣信北少關並內加化由卻代軍產入先山五太水萬市眼體別處總才場師書比住員九笑性通目華報立馬命張活難神數件安表原車白應路期叫死常提感金何更反合放做系計或司利受光王果親界及今京務制解各任至清物台象記邊共風戰干接它許八特覺望直服毛林題建南度統色字請交愛讓認算論百吃義科怎元社術結六功指思非流每青管夫連遠資隊跟帶花快條院變聯言權往展該領傳近留紅治決周保達辦運武半候七必城父強步完革深區即求品士轉量空甚眾技輕程告江語英基派滿式李息寫呢識極令黃德收臉錢黨倒未持取設始版雙歷越史商千片容研像找友孩站廣改議形委早房音火際則首單據導影失拿網香似斯專石若兵弟誰校讀志飛觀爭究包組造落視濟喜離雖壞興切系統內容管理網站模板文章欄目會員登錄註冊首頁搜尋評論標籤圖片下載資訊發佈時間作者來源更新織夢")
+)
+
+// eucKR 用于判断韩文字符是否属于 KS X 1001
+var eucKR = getEncoding("EUC-KR")
+
+// charsetDetectors 按先验权重排列
+var charsetDetectors = []charsetDetector{
+	{"GBK", 0.95, []string{"zh", "zh-cn", "zh-hans", "zh-sg"}, scoreHan(commonHans)},
+	{"GB18030", 0.94, []string{"zh", "zh-cn", "zh-hans", "zh-sg"}, scoreHan(commonHans)},
+	{"BIG5", 0.93, []string{"zh-tw", "zh-hk", "zh-mo", "zh-hant"}, scoreHan(commonHantHans)},
+	{"SHIFT_JIS", 0.92, []string{"ja"}, scoreJapanese},
+	{"EUC-JP", 0.91, []string{"ja"}, scoreJapanese},
+	{"EUC-KR", 0.92, []string{"ko"}, scoreKorean},
+	{"WINDOWS-1252", 0.55, []string{"en", "fr", "de", "es", "it", "pt", "nl", "da", "sv", "no", "fi", "is", "ca"}, scoreAlphabet(unicode.Latin, true)},
+	{"WINDOWS-1251", 0.53, []string{"ru", "uk", "be", "bg", "sr", "mk"}, scoreAlphabet(unicode.Cyrillic, false)},
+	{"WINDOWS-1250", 0.52, []string{"pl", "cs", "sk", "hu", "sl", "hr", "ro"}, scoreAlphabet(unicode.Latin, true)},
+	{"WINDOWS-1253", 0.5, []string{"el"}, scoreAlphabet(unicode.Greek, false)},
+	{"WINDOWS-1254", 0.5, []string{"tr", "az"}, scoreAlphabet(unicode.Latin, true)},
+	{"WINDOWS-1255", 0.5, []string{"he", "yi"}, scoreAlphabet(unicode.Hebrew, false)},
+	{"WINDOWS-1256", 0.5, []string{"ar", "fa", "ur"}, scoreAlphabet(unicode.Arabic, false)},
+	{"WINDOWS-1257", 0.49, []string{"lt", "lv", "et"}, scoreAlphabet(unicode.Latin, true)},
+	{"WINDOWS-1258", 0.49, []string{"vi"}, scoreAlphabet(unicode.Latin, true)},
+}
+
+// DetectCharset 检测内容编码，按可信度从高到低返回候选编码
+// 依次检查BOM、UTF-32/UTF-16、UTF-8，再逐个验证多字节及单字节编码的字节序列并按解码结果评分。
+// 纯ASCII内容返回UTF-8，无法识别（例如二进制内容）时返回空列表。
+// 例子：
+// c := snake.DetectCharset(data, snake.DetectOptions{Language: "zh-CN"})
+func DetectCharset(data []byte, opts ...DetectOptions) []CharsetCandidate {
+	opt := DetectOptions{}
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+
+	for _, v := range charsetBOMs {
+		if bytes.HasPrefix(data, v.bom) {
+			return []CharsetCandidate{{Charset: v.charset, Confidence: 1, BOM: true}}
+		}
+	}
+	if len(data) == 0 {
+		return []CharsetCandidate{{Charset: "UTF-8", Confidence: 1}}
+	}
+
+	// 只检测开头部分 ...
+	if len(data) > sniffSize {
+		data, opt.Partial = data[:sniffSize], true
+	}
+
+	// 没有BOM的UTF-32、UTF-16，根据零字节的位置判断 ...
+	if res := detectUTF16or32(data, opt.Partial); len(res) > 0 {
+		return res
+	}
+	if bytes.IndexByte(data, 0) >= 0 {
+		return []CharsetCandidate{}
+	}
+	if isASCII(data) {
+		return []CharsetCandidate{{Charset: "UTF-8", Confidence: 1}}
+	}
+
+	sample := data
+	if opt.Partial {
+		sample = trimPartialRune(data)
+	}
+
+	var res []CharsetCandidate
+	if utf8.Valid(sample) {
+		// 多字节序列越多，偶然符合UTF-8规则的可能性越小 ...
+		n := 0
+		for _, b := range sample {
+			if b >= 0xc0 {
+				n++
+			}
+		}
+		conf := 0.6 + 0.1*float64(n)
+		if conf > 0.99 {
+			conf = 0.99
+		}
+		res = append(res, CharsetCandidate{Charset: "UTF-8", Confidence: conf})
+	}
+
+	for _, d := range charsetDetectors {
+		e := getEncoding(d.charset)
+		if e == nil {
+			continue
+		}
+		if conf, ok := scoreCharset(data, e, d, opt.Partial); ok {
+			res = append(res, CharsetCandidate{Charset: d.charset, Confidence: conf})
+		}
+	}
+
+	applyCharsetHints(res, opt)
+	res = mergeCharsetHint(data, res, opt)
+
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].Confidence > res[j].Confidence
+	})
+	return res
+}
+
+// DetectCharset 检测内容编码，按可信度从高到低返回候选编码
+func (t *SnakeString) DetectCharset(opts ...DetectOptions) []CharsetCandidate {
+	return DetectCharset(t.Byte(), opts...)
+}
+
+// ---------------------------------------
+// 辅助函数 :
+
+// scoreCharset 按编码解码并评分，字节序列不符合编码规则时返回false
+func scoreCharset(data []byte, e encoding.Encoding, d charsetDetector, partial bool) (float64, bool) {
+	out, err := e.NewDecoder().Bytes(data)
+	if err != nil {
+		return 0, false
+	}
+	rs := []rune(string(out))
+	if partial && len(rs) > 0 && rs[len(rs)-1] == utf8.RuneError {
+		rs = rs[:len(rs)-1]
+	}
+
+	total, score := 0, 0.0
+	for i, r := range rs {
+		if r < 0x80 {
+			continue
+		}
+		// 非法字节序列及用户自定义区 ...
+		if r == utf8.RuneError || (r >= 0xe000 && r <= 0xf8ff) || unicode.IsControl(r) {
+			return 0, false
+		}
+		total++
+		score += d.score(rs, i)
+	}
+	if total == 0 {
+		return 0, false
+	}
+
+	// 非ASCII字节越少，结果越不可靠，按字节计算使单字节与多字节编码的结果可以比较 ...
+	n := 0
+	for _, b := range data {
+		if b >= 0x80 {
+			n++
+		}
+	}
+	length := float64(n+4) / 12
+	if length > 1 {
+		length = 1
+	}
+	return score / float64(total) * d.prior * length, true
+}
+
+// scoreHan 汉字编码评分，常用字得分最高
+func scoreHan(common map[rune]bool) func(rs []rune, i int) float64 {
+	return func(rs []rune, i int) float64 {
+		r := rs[i]
+		switch {
+		case common[r]:
+			return 1
+		case unicode.Is(unicode.Han, r):
+			return 0.6
+		case isCJKPunct(r):
+			return 0.9
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			return 0.2
+		}
+		return 0.05
+	}
+}
+
+// scoreJapanese 日文编码评分，假名得分最高
+func scoreJapanese(rs []rune, i int) float64 {
+	r := rs[i]
+	switch {
+	case r >= 0xff61 && r <= 0xff9f:
+		// 半角片假名 ...
+		return 0.3
+	case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+		return 1
+	case commonHans[r] || commonHantHans[r]:
+		return 0.8
+	case unicode.Is(unicode.Han, r):
+		return 0.5
+	case isCJKPunct(r):
+		return 0.9
+	}
+	return 0.05
+}
+
+// scoreKorean 韩文编码评分，现代韩文很少使用汉字
+// EUC-KR 解码器兼容 CP949，KS X 1001 之外的扩展韩文字符很少使用。
+func scoreKorean(rs []rune, i int) float64 {
+	r := rs[i]
+	switch {
+	case unicode.Is(unicode.Hangul, r):
+		if b, err := eucKR.NewEncoder().Bytes([]byte(string(r))); err == nil && len(b) == 2 && b[0] >= 0xb0 && b[1] >= 0xa1 {
+			return 1
+		}
+		return 0.2
+	case unicode.Is(unicode.Han, r):
+		return 0.1
+	case isCJKPunct(r):
+		return 0.8
+	}
+	return 0.05
+}
+
+// scoreAlphabet 单字节编码评分
+// latin为true时非ASCII字母所在的单词应包含ASCII字母（例如：café），否则单词应全部为非ASCII字母（例如：привет）。
+// 单词中小写字母后出现大写字母时视为不合理。
+func scoreAlphabet(script *unicode.RangeTable, latin bool) func(rs []rune, i int) float64 {
+	return func(rs []rune, i int) float64 {
+		r := rs[i]
+		if !unicode.IsLetter(r) {
+			if strings.ContainsRune("‘’‚“”„–—…•€«»°©®™", r) {
+				return 0.8
+			}
+			return 0.2
+		}
+		if !unicode.Is(script, r) {
+			return 0.1
+		}
+		if unicode.IsUpper(r) && i > 0 && unicode.IsLower(rs[i-1]) {
+			return 0.3
+		}
+
+		// 所在单词是否包含ASCII字母 ...
+		start, end := i, i
+		for start > 0 && unicode.IsLetter(rs[start-1]) {
+			start--
+		}
+		for end < len(rs)-1 && unicode.IsLetter(rs[end+1]) {
+			end++
+		}
+		ascii := false
+		for _, v := range rs[start : end+1] {
+			if v < 0x80 {
+				ascii = true
+				break
+			}
+		}
+		if ascii == latin {
+			return 1
+		}
+		if latin {
+			return 0.5
+		}
+		return 0.3
+	}
+}
+
+// isCJKPunct 判断是否为中日韩标点及全角字符
+func isCJKPunct(r rune) bool {
+	return (r >= 0x3000 && r <= 0x303f) || (r >= 0xff00 && r <= 0xffef) || (r >= 0x2010 && r <= 0x2027) || (r >= 0x2500 && r <= 0x257f)
+}
+
+// detectUTF16or32 根据零字节的位置判断没有BOM的UTF-16及UTF-32
+func detectUTF16or32(data []byte, partial bool) []CharsetCandidate {
+	if partial {
+		data = data[:len(data)-len(data)%4]
+	}
+
+	var res []CharsetCandidate
+	if len(data) >= 4 && len(data)%4 == 0 {
+		var zeros [4]int
+		for i, b := range data {
+			if b == 0 {
+				zeros[i%4]++
+			}
+		}
+		n := len(data) / 4
+		if zeros[3] == n && float64(zeros[2]) >= 0.9*float64(n) {
+			res = append(res, CharsetCandidate{Charset: "UTF-32LE", Confidence: 0.95})
+		} else if zeros[0] == n && float64(zeros[1]) >= 0.9*float64(n) {
+			res = append(res, CharsetCandidate{Charset: "UTF-32BE", Confidence: 0.95})
+		}
+	}
+	if len(res) == 0 && len(data) >= 2 && len(data)%2 == 0 {
+		var even, odd int
+		for i, b := range data {
+			if b == 0 {
+				if i%2 == 0 {
+					even++
+				} else {
+					odd++
+				}
+			}
+		}
+		n := float64(len(data) / 2)
+		switch {
+		case float64(odd) >= 0.3*n && float64(even) < 0.05*n:
+			res = append(res, CharsetCandidate{Charset: "UTF-16LE", Confidence: 0.6 + 0.35*float64(odd)/n})
+		case float64(even) >= 0.3*n && float64(odd) < 0.05*n:
+			res = append(res, CharsetCandidate{Charset: "UTF-16BE", Confidence: 0.6 + 0.35*float64(even)/n})
+		}
+	}
+
+	// 验证解码结果 ...
+	for _, v := range res {
+		out, err := getEncoding(v.Charset).NewDecoder().Bytes(data)
+		if err != nil || bytes.ContainsRune(out, utf8.RuneError) {
+			return nil
+		}
+	}
+	return res
+}
+
+// applyCharsetHints 根据语言提示提高对应编码的可信度
+func applyCharsetHints(res []CharsetCandidate, opt DetectOptions) {
+	lang := strings.ToLower(strings.ReplaceAll(opt.Language, "_", "-"))
+	if lang == "" {
+		return
+	}
+	for i := range res {
+		for _, d := range charsetDetectors {
+			if d.charset != res[i].Charset {
+				continue
+			}
+			for _, l := range d.langs {
+				// zh-TW 不应匹配简体中文的 zh ...
+				if lang == l || (l != "zh" && strings.HasPrefix(lang, l+"-")) {
+					res[i].Confidence = boostConfidence(res[i].Confidence, 1.5)
+				}
+			}
+		}
+	}
+}
+
+// mergeCharsetHint 根据Content-Type中的charset提高对应编码的可信度，未检测到该编码但可以正确解码时加入候选
+func mergeCharsetHint(data []byte, res []CharsetCandidate, opt DetectOptions) []CharsetCandidate {
+	if opt.ContentType == "" {
+		return res
+	}
+	_, params, err := mime.ParseMediaType(opt.ContentType)
+	hint := strings.ToUpper(params["charset"])
+	if err != nil || hint == "" {
+		return res
+	}
+
+	for i := range res {
+		if sameCharset(res[i].Charset, hint) {
+			res[i].Confidence = boostConfidence(res[i].Confidence, 2)
+			return res
+		}
+	}
+	if e := getEncoding(hint); e != nil {
+		if out, err := e.NewDecoder().Bytes(data); err == nil && !bytes.ContainsRune(out, utf8.RuneError) {
+			res = append(res, CharsetCandidate{Charset: hint, Confidence: 0.5})
+		}
+	}
+	return res
+}
+
+// boostConfidence 提高可信度，不超过0.99
+func boostConfidence(conf, factor float64) float64 {
+	conf *= factor
+	if conf > 0.99 {
+		conf = 0.99
+	}
+	return conf
+}
+
+// runeSet 字符集合
+func runeSet(s string) map[rune]bool {
+	m := map[rune]bool{}
+	for _, r := range s {
+		m[r] = true
+	}
+	return m
+}
@@ -0,0 +1,221 @@
+package snake
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// DuplicateAction 重复文件处理方式
+type DuplicateAction int
+
+const (
+	DuplicateNone     DuplicateAction = iota // 仅查找，不做处理
+	DuplicateDelete                          // 保留第一个文件，删除其余副本
+	DuplicateHardlink                        // 保留第一个文件，其余副本替换为硬链接
+	DuplicateSymlink                         // 保留第一个文件，其余副本替换为符号链接
+)
+
+// partialHashSize 部分哈希读取的字节数
+const partialHashSize = 4096
+
+// DuplicateOptions 重复文件查找参数
+type DuplicateOptions struct {
+	MinSize int64           // 最小文件大小，小于该值的文件不参与比较，默认跳过空文件
+	Ignore  []string        // 忽略规则，匹配文件名或相对路径
+	Action  DuplicateAction // 找到重复文件后的处理方式
+}
+
+// DuplicateGroup 一组内容相同的文件
+type DuplicateGroup struct {
+	Size  int64    // 文件大小
+	Hash  string   // 文件SHA256
+	Files []string // 文件路径，按路径排序，第一个为保留的原件
+}
+
+// FindDuplicates 在多个目录中查找内容相同的文件
+// 依次按文件大小、部分哈希、完整SHA256分组，避免对所有文件做完整哈希。
+// 目录重叠时同一文件只计一次，指向同一inode的硬链接不视为重复文件。
+// 例子：
+// groups, err := snake.FindDuplicates([]snake.FileSystem{snake.FS("./uploads")}, snake.DuplicateOptions{Action: snake.DuplicateHardlink})
+func FindDuplicates(dirs []FileSystem, opts ...DuplicateOptions) ([]DuplicateGroup, error) {
+	opt := DuplicateOptions{}
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.MinSize < 1 {
+		opt.MinSize = 1
+	}
+
+	// 按文件大小分组 ...
+	sizes := map[int64][]string{}
+	infos := map[int64][]os.FileInfo{}
+	seen := map[string]bool{}
+	for _, dir := range dirs {
+		root := dir.Get()
+		err := filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if rel, err := filepath.Rel(root, p); err == nil && rel != "." && matchPatterns(rel, opt.Ignore) {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !info.Mode().IsRegular() || info.Size() < opt.MinSize {
+				return nil
+			}
+
+			// 目录重叠或写法不同时同一文件会被多次遍历，按绝对路径及inode去重 ...
+			key, err := filepath.Abs(p)
+			if err != nil {
+				return err
+			}
+			if real, err := filepath.EvalSymlinks(key); err == nil {
+				key = real
+			}
+			if seen[key] {
+				return nil
+			}
+			seen[key] = true
+			for _, v := range infos[info.Size()] {
+				if os.SameFile(v, info) {
+					return nil
+				}
+			}
+			infos[info.Size()] = append(infos[info.Size()], info)
+			sizes[info.Size()] = append(sizes[info.Size()], p)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var res []DuplicateGroup
+	for size, files := range sizes {
+		if len(files) < 2 {
+			continue
+		}
+
+		// 按部分哈希分组 ...
+		for _, partial := range groupByHash(files, partialHash) {
+			// 按完整SHA256分组 ...
+			full := groupByHash(partial, func(p string) (string, error) {
+				return FS(p).SHA256(), nil
+			})
+			for hash, group := range full {
+				if hash == "" {
+					continue
+				}
+				sort.Strings(group)
+				res = append(res, DuplicateGroup{Size: size, Hash: hash, Files: group})
+			}
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Files[0] < res[j].Files[0]
+	})
+
+	if opt.Action != DuplicateNone {
+		for _, g := range res {
+			if err := g.Resolve(opt.Action); err != nil {
+				return res, err
+			}
+		}
+	}
+
+	return res, nil
+}
+
+// Resolve 按指定方式处理重复文件，第一个文件作为原件保留
+func (g DuplicateGroup) Resolve(action DuplicateAction) error {
+	if len(g.Files) < 2 || action == DuplicateNone {
+		return nil
+	}
+
+	origin := g.Files[0]
+	info, err := os.Stat(origin)
+	if err != nil {
+		return err
+	}
+	for _, p := range g.Files[1:] {
+		// 与原件为同一文件时跳过，避免删除唯一的副本或链接到自身 ...
+		if v, err := os.Stat(p); err != nil {
+			return err
+		} else if os.SameFile(info, v) {
+			continue
+		}
+
+		if action == DuplicateDelete {
+			if err := os.Remove(p); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// 先在同目录建立临时链接，再替换原文件，避免中途失败丢失副本 ...
+		tmp := p + ".snake-link"
+		os.Remove(tmp)
+
+		var err error
+		if action == DuplicateHardlink {
+			err = os.Link(origin, tmp)
+		} else {
+			var target string
+			if target, err = filepath.Abs(origin); err == nil {
+				err = os.Symlink(target, tmp)
+			}
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := os.Rename(tmp, p); err != nil {
+			os.Remove(tmp)
+			return err
+		}
+	}
+	return nil
+}
+
+// groupByHash 根据哈希函数对文件分组，仅返回包含多个文件的分组
+func groupByHash(files []string, fn func(string) (string, error)) map[string][]string {
+	groups := map[string][]string{}
+	for _, p := range files {
+		if h, err := fn(p); err == nil {
+			groups[h] = append(groups[h], p)
+		}
+	}
+	for h, g := range groups {
+		if len(g) < 2 {
+			delete(groups, h)
+		}
+	}
+	return groups
+}
+
+// partialHash 计算文件开头及结尾部分内容的哈希
+func partialHash(p string) (string, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	if _, err := io.CopyN(hash, f, partialHashSize); err != nil && err != io.EOF {
+		return "", err
+	}
+	if info, err := f.Stat(); err == nil && info.Size() > partialHashSize*2 {
+		if _, err := f.Seek(-partialHashSize, io.SeekEnd); err == nil {
+			io.CopyN(hash, f, partialHashSize)
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
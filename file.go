@@ -2,11 +2,19 @@ package snake
 
 import (
 	"bytes"
+	"context"
+	"errors"
+	"io"
 	"os"
+	"time"
 )
 
+// ErrFileNotOpen 文件未打开
+var ErrFileNotOpen = errors.New("file not open")
+
 type snakefile struct {
 	Input *os.File
+	err   error // 打开失败时的错误
 }
 
 // FileOperate ...
@@ -15,16 +23,41 @@ type FileOperate interface {
 	String() *SnakeString
 	Byte() []byte
 	Close() error // 关闭文件链接
+
+	io.Reader
+	io.Writer
+	io.Seeker
+	io.ReaderAt
+	io.WriterTo
+	Stat() (os.FileInfo, error) // 文件信息
+	Truncate(size int64) error  // 修改文件大小
+	Sync() error                // 写入磁盘
+	Name() string               // 文件路径
+	Err() error                 // 打开文件时的错误
+
+	Lines(max ...int) *LineIterator                                      // 逐行读取
+	Head(n int) ([]string, error)                                        // 读取开头n行
+	Tail(n int) ([]string, error)                                        // 读取末尾n行
+	Follow(ctx context.Context, interval ...time.Duration) <-chan string // 持续读取追加的行
 }
 
 // ---------------------------------------
 // 输入 :
 
 // File 初始化...
+// f为nil时返回的FileOperate所有操作均返回 ErrFileNotOpen。
 func File(f *os.File) FileOperate {
+	if f == nil {
+		return &snakefile{err: ErrFileNotOpen}
+	}
 	return &snakefile{Input: f}
 }
 
+// fileError 打开失败的文件，所有操作均返回err
+func fileError(err error) FileOperate {
+	return &snakefile{err: err}
+}
+
 // ---------------------------------------
 // 输出 :
 
@@ -35,11 +68,17 @@ func (sk *snakefile) Get() *os.File {
 
 // Add 在字符串中追加文字...
 func (sk *snakefile) Close() error {
+	if sk.Input == nil {
+		return sk.err
+	}
 	return sk.Input.Close()
 }
 
 // Text 获取文本...
 func (sk *snakefile) String() *SnakeString {
+	if sk.Input == nil {
+		return String()
+	}
 	var buf bytes.Buffer
 	_, err := buf.ReadFrom(sk.Input)
 	if err != nil {
@@ -52,6 +91,9 @@ func (sk *snakefile) String() *SnakeString {
 
 // Text 获取文本 []byte ...
 func (sk *snakefile) Byte() []byte {
+	if sk.Input == nil {
+		return nil
+	}
 	var buf bytes.Buffer
 	_, err := buf.ReadFrom(sk.Input)
 	if err != nil {
@@ -59,3 +101,83 @@ func (sk *snakefile) Byte() []byte {
 	}
 	return buf.Bytes()
 }
+
+// ---------------------------------------
+// 读写 :
+
+// Err 打开文件时的错误，打开成功时为nil
+func (sk *snakefile) Err() error {
+	return sk.err
+}
+
+// Read 实现 io.Reader
+func (sk *snakefile) Read(p []byte) (int, error) {
+	if sk.Input == nil {
+		return 0, sk.err
+	}
+	return sk.Input.Read(p)
+}
+
+// Write 实现 io.Writer
+func (sk *snakefile) Write(p []byte) (int, error) {
+	if sk.Input == nil {
+		return 0, sk.err
+	}
+	return sk.Input.Write(p)
+}
+
+// Seek 实现 io.Seeker
+func (sk *snakefile) Seek(offset int64, whence int) (int64, error) {
+	if sk.Input == nil {
+		return 0, sk.err
+	}
+	return sk.Input.Seek(offset, whence)
+}
+
+// ReadAt 实现 io.ReaderAt
+func (sk *snakefile) ReadAt(p []byte, off int64) (int, error) {
+	if sk.Input == nil {
+		return 0, sk.err
+	}
+	return sk.Input.ReadAt(p, off)
+}
+
+// WriteTo 实现 io.WriterTo，从当前位置读取到文件末尾并写入w
+func (sk *snakefile) WriteTo(w io.Writer) (int64, error) {
+	if sk.Input == nil {
+		return 0, sk.err
+	}
+	return io.Copy(w, sk.Input)
+}
+
+// Stat 文件信息
+func (sk *snakefile) Stat() (os.FileInfo, error) {
+	if sk.Input == nil {
+		return nil, sk.err
+	}
+	return sk.Input.Stat()
+}
+
+// Truncate 修改文件大小
+func (sk *snakefile) Truncate(size int64) error {
+	if sk.Input == nil {
+		return sk.err
+	}
+	return sk.Input.Truncate(size)
+}
+
+// Sync 将缓存写入磁盘
+func (sk *snakefile) Sync() error {
+	if sk.Input == nil {
+		return sk.err
+	}
+	return sk.Input.Sync()
+}
+
+// Name 文件路径
+func (sk *snakefile) Name() string {
+	if sk.Input == nil {
+		return ""
+	}
+	return sk.Input.Name()
+}
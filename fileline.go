@@ -0,0 +1,232 @@
+package snake
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"time"
+)
+
+// DefaultMaxLineLength 默认最大行长度
+const DefaultMaxLineLength = 64 * 1024
+
+// tailBlockSize Tail 每次向前读取的块大小
+const tailBlockSize = 4096
+
+// LineIterator 逐行读取文件
+// 行尾的 \n 与 \r\n 会被去除，超过最大长度的行返回 bufio.ErrTooLong。
+type LineIterator struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+// Next 读取下一行，没有更多内容或出错时返回false
+func (it *LineIterator) Next() bool {
+	if it.scanner.Scan() {
+		it.line++
+		return true
+	}
+	return false
+}
+
+// Text 当前行内容
+func (it *LineIterator) Text() string {
+	return it.scanner.Text()
+}
+
+// Bytes 当前行内容，下一次调用 Next 后失效
+func (it *LineIterator) Bytes() []byte {
+	return it.scanner.Bytes()
+}
+
+// Line 当前行号，从1开始
+func (it *LineIterator) Line() int {
+	return it.line
+}
+
+// Err 读取过程中的错误
+func (it *LineIterator) Err() error {
+	return it.scanner.Err()
+}
+
+// Lines 从当前位置逐行读取，max为最大行长度，默认 DefaultMaxLineLength
+// 例子：
+// it := f.Lines(); for it.Next() { fmt.Println(it.Text()) }
+func (sk *snakefile) Lines(max ...int) *LineIterator {
+	size := DefaultMaxLineLength
+	if len(max) > 0 && max[0] > 0 {
+		size = max[0]
+	}
+	scanner := bufio.NewScanner(sk)
+	buf := 4096
+	if buf > size {
+		buf = size
+	}
+	// 预留行尾 \r\n 的空间 ...
+	scanner.Buffer(make([]byte, 0, buf), size+2)
+	return &LineIterator{scanner: scanner}
+}
+
+// Head 读取文件开头n行
+func (sk *snakefile) Head(n int) ([]string, error) {
+	if _, err := sk.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	lines := []string{}
+	it := sk.Lines()
+	for len(lines) < n && it.Next() {
+		lines = append(lines, it.Text())
+	}
+	return lines, it.Err()
+}
+
+// Tail 读取文件末尾n行，从文件末尾向前按块读取，不读取整个文件
+func (sk *snakefile) Tail(n int) ([]string, error) {
+	if n <= 0 {
+		return []string{}, nil
+	}
+	info, err := sk.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	end := info.Size()
+	var data []byte
+	for pos := end; pos > 0; {
+		size := int64(tailBlockSize)
+		if pos < size {
+			size = pos
+		}
+		pos -= size
+		block := make([]byte, size)
+		if _, err := sk.ReadAt(block, pos); err != nil && err != io.EOF {
+			return nil, err
+		}
+		data = append(block, data...)
+
+		// 末尾的换行不计入行数，多读一个换行以确定第一行的起点 ...
+		if bytes.Count(bytes.TrimSuffix(data, []byte("\n")), []byte("\n")) >= n {
+			break
+		}
+	}
+
+	data = bytes.TrimSuffix(data, []byte("\n"))
+	if len(data) == 0 {
+		return []string{}, nil
+	}
+	lines := bytes.Split(data, []byte("\n"))
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	out := make([]string, len(lines))
+	for i, v := range lines {
+		out[i] = string(bytes.TrimSuffix(v, []byte("\r")))
+	}
+	return out, nil
+}
+
+// Follow 从文件末尾开始持续输出追加的行，与 tail -F 相同：
+// 文件被截断时从头读取，文件被轮转（删除或重命名后重新创建）时重新打开同名文件。
+// ctx 结束后关闭返回的channel，interval为轮询间隔，默认250毫秒。
+func (sk *snakefile) Follow(ctx context.Context, interval ...time.Duration) <-chan string {
+	tick := 250 * time.Millisecond
+	if len(interval) > 0 && interval[0] > 0 {
+		tick = interval[0]
+	}
+
+	out := make(chan string)
+	if sk.Input == nil {
+		close(out)
+		return out
+	}
+	go func() {
+		defer close(out)
+
+		name := sk.Input.Name()
+		f := sk.Input
+		owned := false
+		defer func() {
+			if owned {
+				f.Close()
+			}
+		}()
+
+		offset, _ := f.Seek(0, io.SeekEnd)
+		info, _ := f.Stat()
+		var partial []byte
+		buf := make([]byte, 32*1024)
+
+		ticker := time.NewTicker(tick)
+		defer ticker.Stop()
+
+		for {
+			// 读取新增内容 ...
+			for {
+				n, err := f.Read(buf)
+				if n > 0 {
+					offset += int64(n)
+					partial = append(partial, buf[:n]...)
+					for {
+						i := bytes.IndexByte(partial, '\n')
+						if i < 0 {
+							break
+						}
+						line := string(bytes.TrimSuffix(partial[:i], []byte("\r")))
+						partial = partial[i+1:]
+						select {
+						case out <- line:
+						case <-ctx.Done():
+							return
+						}
+					}
+				}
+				if err != nil || n == 0 {
+					break
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			// 检查截断及轮转 ...
+			cur, err := os.Stat(name)
+			if err != nil {
+				continue
+			}
+			if info != nil && !os.SameFile(info, cur) {
+				nf, err := os.Open(name)
+				if err != nil {
+					continue
+				}
+				// 读完旧文件剩余内容后再切换 ...
+				if rest, err := io.ReadAll(f); err == nil && len(rest) > 0 {
+					partial = append(partial, rest...)
+				}
+				if len(partial) > 0 {
+					select {
+					case out <- string(bytes.TrimSuffix(partial, []byte("\r"))):
+					case <-ctx.Done():
+						nf.Close()
+						return
+					}
+				}
+				if owned {
+					f.Close()
+				}
+				f, owned, info, offset, partial = nf, true, cur, 0, nil
+				continue
+			}
+			info = cur
+			if cur.Size() < offset {
+				offset, _ = f.Seek(0, io.SeekStart)
+				partial = nil
+			}
+		}
+	}()
+	return out
+}
@@ -8,6 +8,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/jinzhu/configor"
 )
@@ -26,7 +27,9 @@ type FileSystem interface {
 	MkFile(dst ...string) (FileOperate, bool)         // 新建文件
 	Write(src string, add ...bool) bool               // 写入文件
 	ByteWriter(src []byte, add ...bool) (bool, error) // 通过Byte数组写入文件
+	AtomicWriter(src []byte) error                    // 原子写入文件
 	Open(add ...bool) (FileOperate, bool)             // 打开文件
+	OpenFile(opt ...OpenOptions) (FileOperate, error) // 按指定模式打开文件
 	Exist(dst ...string) bool                         // 判断目录或文件是否存在
 	Rm(dst ...string) bool                            // 删除目录或文件
 	Rn(newname string) bool                           // 修改目录或文件名
@@ -36,17 +39,71 @@ type FileSystem interface {
 	// Chmod()                         // 设置权限
 	// Chown()                         // 设置用户、用户组
 
-	Ext() string // 返回文件扩展名
-	MimeTypes() string
+	Ext() string                   // 返回文件扩展名
+	MimeTypes() string             // 根据扩展名返回MimeTypes
+	ContentType() string           // 根据文件内容返回MimeTypes
+	MimeDetect() MimeInfo          // 对比扩展名与文件内容的MimeTypes
 	MD5() string                   // 返回文件MD5
 	SHA256() string                // 返回文件SHA256
 	Config(conf interface{}) error // 加载配置文件
 	Get() string                   // 返回路径
 	Unzip() (string, error)
+	TreeHash(opts ...TreeHashOptions) (*TreeHashResult, error) // 计算目录Merkle哈希
+
+	Links(policy LinkPolicy) FileSystem // 设置符号链接处理方式
+	LinkPolicy() LinkPolicy             // 返回符号链接处理方式
+	Lstat() (os.FileInfo, error)        // 获取文件信息，不跟随符号链接
+	IsSymlink(dst ...string) bool       // 判断是否为符号链接
+	Lexist(dst ...string) bool          // 判断是否存在，不跟随符号链接
+	Readlink() (string, error)          // 返回符号链接指向的路径
+	Symlink(target string) bool         // 创建指向target的符号链接
+	Hardlink(target string) bool        // 创建指向target的硬链接
+
+	Lock() (*FileLock, error)                         // 获取排它锁
+	RLock() (*FileLock, error)                        // 获取共享锁
+	TryLock(timeout time.Duration) (*FileLock, error) // 在指定时间内尝试获取排它锁
+	WithLock(fn func() error) error                   // 获取排它锁后执行fn
+	AutoLock(on ...bool) FileSystem                   // 写入时自动加锁
+
+	Stat() (*FileStat, error)  // 获取文件信息
+	Touch(t ...time.Time) bool // 修改文件访问及修改时间，不存在时创建
+	Du() (*DiskUsage, error)   // 统计目录占用空间
+
+	Tree(opts ...TreeOptions) *SnakeString // 绘制目录树
+
+	Trash() (string, error) // 移动到回收站
+
+	RmWith(opts ...RmOptions) (*RmPlan, error) // 带保护的删除，支持试运行及确认
+
+	LoadConfig(conf interface{}, opts ...ConfigOptions) (ConfigReport, error) // 分层加载配置文件
+	SaveConfig(conf interface{}) error                                        // 写入配置文件
+
+	WatchConfig(conf interface{}, onChange func(conf interface{}, err error), interval ...time.Duration) (*ConfigWatcher, error) // 热加载配置文件
+
+	Rel(base string) (FileSystem, error) // 返回相对于base的路径
+	Abs() FileSystem                     // 返回绝对路径
+	Stem() string                        // 返回不含扩展名的文件名
+	WithExt(ext string) FileSystem       // 替换扩展名
+	WithBase(name string) FileSystem     // 替换路径中最后一个元素
+	Parent(n ...int) FileSystem          // 返回上层目录
+	Parts() []string                     // 返回路径中的各个元素
+	Match(pattern string) bool           // 判断路径是否符合规则
+	IsWithin(base string) bool           // 判断路径是否位于base目录内
+	Expand() FileSystem                  // 展开路径中的"~"及环境变量
+
+	PHPConfig() (*PHPConfig, error)                    // 解析PHP配置文件
+	SetPHPConfig(name string, value interface{}) error // 修改PHP配置文件中的变量或常量
+
+	ReadText(opts ...TextOptions) (*SnakeString, string, error) // 读取文本并转换为UTF-8
+	WriteText(s string, charset string) error                   // 按指定编码写入文本
+
+	Transcode(from, to string, opts ...TranscodeOptions) ([]TranscodeResult, error) // 批量转换目录中文件的编码
 }
 
 type snakeFileSystem struct {
-	Path string
+	Path     string
+	links    LinkPolicy
+	autolock bool
 }
 
 // ---------------------------------------
@@ -68,24 +125,36 @@ func (sk *snakeFileSystem) Add(str ...string) FileSystem {
 	return sk
 }
 
+// derive 根据当前FileSystem的设置创建新的FileSystem
+func (sk *snakeFileSystem) derive(str ...string) FileSystem {
+	n := &snakeFileSystem{links: sk.links, autolock: sk.autolock}
+	return n.Add(str...)
+}
+
 // ---------------------------------------
 // 处理 :
 
-// Add 在字符串中追加文字...
+// ReplaceRoot 替换根目录位置，未设置新根目录或路径为空时返回当前路径的副本
+// 例子：
+// snake.FS("a/b/c").ReplaceRoot("d")
+// 返回：d/b/c
 func (sk *snakeFileSystem) ReplaceRoot(str ...string) FileSystem {
-	path := String(sk.Path).Split("/")
+	path := String(filepath.ToSlash(sk.Path)).Split("/")
+	if len(str) == 0 || len(path) == 0 || sk.Path == "" {
+		return FS(sk.Path)
+	}
 	path[0] = str[0]
 	return FS(path...)
 }
 
-// Cp 拷贝目录或文件
+// Cp 拷贝目录或文件到dir目录下
+// 符号链接的处理方式由 Links() 设置：默认复制链接指向的内容，LinkNoFollow 时复制链接本身。
 func (sk *snakeFileSystem) Cp(dir string, overwrite bool) bool {
-	dstroot := FS(dir)
-	dst := FS(dir).Add(sk.Base())
+	dst := sk.derive(dir, sk.Base())
 
 	// todo:目标存在则返回错误
 
-	if dst.Exist() && !overwrite {
+	if dst.Lexist() && !overwrite {
 		return false
 	}
 
@@ -94,58 +163,111 @@ func (sk *snakeFileSystem) Cp(dir string, overwrite bool) bool {
 		return false
 	}
 
-	if sk.IsFile() {
-		return _owcpfile(sk, dst)
-	} else if sk.IsDir() {
-		// 覆盖拷贝目录
-		if dst.Exist() {
-			dst.Rm()
-		}
+	if !sk.Lexist() {
+		return false
+	}
 
-		for _, v := range sk.Find("*") {
-			src := FS(v)
-			if src.IsFile() {
-				_owcpfile(src, FS(dstroot.Get(), String(src.Get()).ReplaceOne(sk.Get(), "").Get()))
-			} else if sk.IsDir() {
-				FS(dstroot.Get(), src.Get()).MkDir()
-			}
-		}
+	// 覆盖拷贝
+	if dst.Lexist() {
+		dst.Rm()
 	}
 
-	return true
+	policy := sk.links
+	if policy == LinkDefault {
+		policy = LinkFollow
+	}
+	return copyTree(sk.Get(), dst.Get(), policy) == nil
 }
 
 // Rm 删除目录及文件
+// 符号链接只删除链接本身，不受 Links() 影响，不会删除链接指向的目录或文件。
+// 空路径、根目录、受保护路径及SetRmBase之外的路径不会被删除，详见 RmWith。
 func (sk *snakeFileSystem) Rm(dst ...string) bool {
-	return os.RemoveAll(sk.pathdst(dst...)) == nil
+	p := sk.pathdst(dst...)
+	if rmCheck(p, "") != nil {
+		return false
+	}
+	return os.RemoveAll(p) == nil
 }
 
-// Open 打开文件
+// Open 打开文件，add为true时以追加方式打开
+// 打开失败时返回的FileOperate不为nil，所有操作均返回打开时的错误，详见 OpenFile。
 func (sk *snakeFileSystem) Open(add ...bool) (FileOperate, bool) {
 	if len(add) > 0 && add[0] {
-		file, err := os.OpenFile(sk.Path, os.O_APPEND|os.O_WRONLY, os.ModeAppend)
-		return File(file), err == nil
+		f, err := sk.OpenFile(OpenOptions{Append: true})
+		return f, err == nil
 	}
-	file, err := os.Open(sk.Path)
-	return File(file), err == nil
+	f, err := sk.OpenFile()
+	return f, err == nil
+}
+
+// OpenOptions 打开文件的模式
+// 未设置 Write 及 Append 时以只读方式打开。
+type OpenOptions struct {
+	Read      bool        // 读取，与 Write 或 Append 同时设置时以读写方式打开
+	Write     bool        // 写入
+	Append    bool        // 追加写入
+	Create    bool        // 文件不存在时创建，同时创建上级目录
+	Exclusive bool        // 文件已存在时返回错误，隐含 Create
+	Truncate  bool        // 打开时清空文件
+	Perm      os.FileMode // 创建文件时的权限，默认0644
+}
+
+// OpenFile 按指定模式打开文件，失败时返回的FileOperate不为nil，所有操作均返回该错误
+// 例子：
+// f, err := snake.FS("logs/app.log").OpenFile(snake.OpenOptions{Append: true, Create: true})
+func (sk *snakeFileSystem) OpenFile(opt ...OpenOptions) (FileOperate, error) {
+	o := OpenOptions{}
+	if len(opt) > 0 {
+		o = opt[0]
+	}
+
+	flag := os.O_RDONLY
+	if o.Write || o.Append {
+		flag = os.O_WRONLY
+		if o.Read {
+			flag = os.O_RDWR
+		}
+	}
+	if o.Append {
+		flag |= os.O_APPEND
+	}
+	if o.Create || o.Exclusive {
+		flag |= os.O_CREATE
+	}
+	if o.Exclusive {
+		flag |= os.O_EXCL
+	}
+	if o.Truncate {
+		flag |= os.O_TRUNC
+	}
+	perm := o.Perm
+	if perm == 0 {
+		perm = 0644
+	}
+
+	if flag&os.O_CREATE != 0 {
+		if err := os.MkdirAll(sk.Dir(), os.ModePerm); err != nil {
+			return fileError(err), err
+		}
+	}
+	file, err := os.OpenFile(sk.Path, flag, perm)
+	if err != nil {
+		return fileError(err), err
+	}
+	return File(file), nil
 }
 
 // Rn 修改目录或文件名
+// 不修改当前路径，新路径可通过 WithBase(newname) 获取。
 func (sk *snakeFileSystem) Rn(newname string) bool {
-	res := os.Rename(sk.Path, filepath.Join(sk.Dir(), newname)) == nil
-	if res {
-		sk.Path = filepath.Join(sk.Dir(), newname)
-	}
-	return res
+	return os.Rename(sk.Path, filepath.Join(sk.Dir(), newname)) == nil
 }
 
 // Mv 移动目录或文件到指定位置
+// 不修改当前路径，新路径可通过 FS(newpath, Base()) 获取。
 func (sk *snakeFileSystem) Mv(newpath string) bool {
-	res := os.Rename(sk.Path, filepath.Join(newpath, sk.Base())) == nil
-	if res {
-		sk.Path = filepath.Join(newpath, sk.Base())
-	}
-	return res
+	return os.Rename(sk.Path, filepath.Join(newpath, sk.Base())) == nil
 }
 
 // Ext 扩展名
@@ -155,7 +277,7 @@ func (sk *snakeFileSystem) Ext() string {
 
 // MimeTypes 根据文件名获取MimeTypes
 func (sk *snakeFileSystem) MimeTypes() string {
-	return mimeTypes[String(sk.Ext()).Trim(".").ToLower().Get()]
+	return mimeTypes.Get(sk.Ext())
 }
 
 // MD5 获取文件的MD5
@@ -192,7 +314,10 @@ func (sk *snakeFileSystem) MkFile(dst ...string) (FileOperate, bool) {
 		sk.MkDir(p.Dir())
 	}
 	file, err := os.Create(p.Get())
-	return File(file), err == nil
+	if err != nil {
+		return fileError(err), false
+	}
+	return File(file), true
 }
 
 // Write 写入文件, Add为是否追加写入，默认为覆盖写入
@@ -208,6 +333,12 @@ func (sk *snakeFileSystem) ByteWriter(src []byte, add ...bool) (bool, error) {
 	var f *os.File
 	var err error
 
+	unlock, err := sk.autoLock()
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
 	if sk.Exist() && sk.IsFile() {
 		if len(add) > 0 && add[0] {
 			f, err = os.OpenFile(sk.Path, os.O_APPEND|os.O_WRONLY, os.ModeAppend)
@@ -233,6 +364,39 @@ func (sk *snakeFileSystem) ByteWriter(src []byte, add ...bool) (bool, error) {
 	return false, err
 }
 
+// AtomicWriter 原子写入文件：先写入同目录下的临时文件，再替换原文件，
+// 读取方不会读到写了一半的内容。
+func (sk *snakeFileSystem) AtomicWriter(src []byte) error {
+	unlock, err := sk.autoLock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	perm := os.FileMode(0644)
+	if info, err := os.Stat(sk.Path); err == nil {
+		perm = info.Mode().Perm()
+	}
+
+	if !FS(sk.Dir()).Exist() {
+		sk.MkDir(sk.Dir())
+	}
+
+	tmp, _, err := TempFile("."+sk.Base()+".*", sk.Dir())
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Get())
+
+	if err := os.WriteFile(tmp.Get(), src, perm); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Get(), perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Get(), sk.Path)
+}
+
 // Exist 判断文件或目录是否存在
 func (sk *snakeFileSystem) Exist(dst ...string) bool {
 	if _, err := os.Stat(sk.pathdst(dst...)); err != nil {
@@ -259,9 +423,20 @@ func (sk *snakeFileSystem) Ls(opt ...string) []string {
 
 // Find 根据条件搜索路径目录下内容
 // 功能与Ls()方法一直，区别在于Find可以对当前路径下所有目录遍历搜索并返回列表。
+// 符号链接指向的目录默认不进入，Links(LinkFollow) 时进入并跳过循环链接。
 func (sk *snakeFileSystem) Find(opt ...string) []string {
 	if len(opt) == 0 {
-		return walkPath(sk.Path, "*")
+		opt = []string{"*"}
+	}
+	if sk.links == LinkFollow {
+		var res []string
+		walkTree(sk.Path, sk.links, func(p string, info os.FileInfo) error {
+			if info.IsDir() {
+				res = append(res, ls(p, baseSlice(opt)...)...)
+			}
+			return nil
+		})
+		return res
 	}
 	return walkPath(sk.Path, opt...)
 }
@@ -278,7 +453,7 @@ func (sk *snakeFileSystem) Base() string {
 
 // IsDir 判断是否是目录
 func (sk *snakeFileSystem) IsDir(dst ...string) bool {
-	if i, err := os.Stat(sk.pathdst(dst...)); !os.IsExist(err) {
+	if i, err := os.Stat(sk.pathdst(dst...)); err == nil {
 		return i.Mode().IsDir()
 	}
 	return false
@@ -286,7 +461,7 @@ func (sk *snakeFileSystem) IsDir(dst ...string) bool {
 
 // IsFile 判断是否是目录
 func (sk *snakeFileSystem) IsFile(dst ...string) bool {
-	if i, err := os.Stat(sk.pathdst(dst...)); !os.IsExist(err) {
+	if i, err := os.Stat(sk.pathdst(dst...)); err == nil {
 		return i.Mode().IsRegular()
 	}
 	return false
@@ -310,11 +485,17 @@ func (sk *snakeFileSystem) Config(conf interface{}) error {
 	return configor.Load(conf, sk.Path)
 }
 
+// Unzip 解压zip文件到同目录下与文件同名的目录，压缩包中路径越界的条目会返回错误
 func (sk *snakeFileSystem) Unzip() (string, error) {
+	return unzip(sk.Get(), nil)
+}
 
+// unzip 解压zip文件，check不为nil时在写入每个条目前校验目标路径
+func unzip(src string, check func(p string) error) (string, error) {
+	sk := FS(src)
 	base := FS(sk.Dir()).Add(String(sk.Base()).Remove(sk.Ext()).Get())
 
-	z, err := zip.OpenReader(sk.Path)
+	z, err := zip.OpenReader(src)
 
 	if err != nil {
 		return base.Get(), err
@@ -328,6 +509,16 @@ func (sk *snakeFileSystem) Unzip() (string, error) {
 
 		item := FS(base.Get()).Add(file.Name)
 
+		// 防止压缩包中的 ../ 路径写出解压目录
+		if !item.IsWithin(base.Get()) {
+			return base.Get(), &os.PathError{Op: "unzip", Path: file.Name, Err: os.ErrPermission}
+		}
+		if check != nil {
+			if err := check(item.Get()); err != nil {
+				return base.Get(), err
+			}
+		}
+
 		// 如果是目录，则创建目录
 		if file.FileInfo().IsDir() && item.MkDir() {
 			continue
@@ -5,11 +5,13 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"unicode"
 	"unicode/utf8"
 
 	"golang.org/x/text/encoding"
 	"golang.org/x/text/encoding/ianaindex"
+	"golang.org/x/text/encoding/unicode/utf32"
 )
 
 var Len = utf8.RuneCountInString
@@ -73,6 +75,15 @@ func _owcpfile(src FileSystem, dst FileSystem) bool {
 }
 
 func getEncoding(charset string) encoding.Encoding {
+	// GB2312 不在IANA索引的可用编码中，使用兼容的GBK，UTF-32 不在IANA索引中 ...
+	switch strings.ToUpper(charset) {
+	case "GB2312":
+		charset = "GBK"
+	case "UTF-32LE":
+		return utf32.UTF32(utf32.LittleEndian, utf32.IgnoreBOM)
+	case "UTF-32BE":
+		return utf32.UTF32(utf32.BigEndian, utf32.IgnoreBOM)
+	}
 	if e, err := ianaindex.MIB.Encoding(charset); err == nil && e != nil {
 		return e
 	}
@@ -90,3 +101,121 @@ func prenum(data byte) int {
 	}
 	return i
 }
+
+// matchPatterns 判断路径是否符合规则列表中的任意一条
+// 规则会同时与文件名及相对路径进行匹配。
+func matchPatterns(rel string, patterns []string) bool {
+	rel = filepath.ToSlash(rel)
+	name := filepath.Base(rel)
+	for _, v := range patterns {
+		v = filepath.ToSlash(v)
+		if ok, _ := filepath.Match(v, name); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(v, rel); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// isGBKBytes 判断字节序列是否符合GBK编码规则
+func isGBKBytes(data []byte) bool {
+	for i := 0; i < len(data); i++ {
+		if data[i] < 0x80 {
+			continue
+		}
+		if data[i] < 0x81 || data[i] > 0xfe || i+1 >= len(data) {
+			return false
+		}
+		if data[i+1] < 0x40 || data[i+1] > 0xfe || data[i+1] == 0x7f {
+			return false
+		}
+		i++
+	}
+	return true
+}
+
+// isSingleByteCharset 判断是否为单字节编码
+func isSingleByteCharset(charset string) bool {
+	charset = strings.ToUpper(charset)
+	return strings.HasPrefix(charset, "ISO-8859") || strings.HasPrefix(charset, "WINDOWS-125")
+}
+
+// baseSlice 返回规则列表中每条规则的最后一个元素
+func baseSlice(dst []string) []string {
+	res := make([]string, len(dst))
+	for i, v := range dst {
+		res[i] = filepath.Base(v)
+	}
+	return res
+}
+
+// formatSize 将字节数转换为易读格式，例如：1.5K、20M
+func formatSize(size int64) string {
+	if size < 1024 {
+		return fmt.Sprintf("%4d", size)
+	}
+	value := float64(size)
+	for _, unit := range []string{"K", "M", "G", "T", "P"} {
+		value /= 1024
+		if value < 1024 || unit == "P" {
+			if value < 10 {
+				return fmt.Sprintf("%3.1f%s", value, unit)
+			}
+			return fmt.Sprintf("%3.0f%s", value, unit)
+		}
+	}
+	return fmt.Sprint(size)
+}
+
+// copyTree 复制目录或文件到dst，符号链接按policy处理
+func copyTree(src, dst string, policy LinkPolicy) error {
+	return walkTree(src, policy, func(p string, info os.FileInfo) error {
+		target := dst
+		if rel, err := filepath.Rel(src, p); err == nil && rel != "." {
+			target = filepath.Join(dst, rel)
+		}
+
+		switch {
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(p)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case info.IsDir():
+			return os.MkdirAll(target, os.ModePerm)
+		case info.Mode().IsRegular():
+			if !_owcpfile(FS(p), FS(target)) {
+				return &os.PathError{Op: "copy", Path: p, Err: os.ErrInvalid}
+			}
+			return os.Chmod(target, info.Mode().Perm())
+		}
+		return nil
+	})
+}
+
+// archiveIgnored 判断是否为不写入压缩包的系统文件
+func archiveIgnored(path string) bool {
+	for _, v := range []string{".DS_Store", "__MACOSX", ".gitignore", ".index"} {
+		if String(path).Find(v, true) {
+			return true
+		}
+	}
+	return false
+}
+
+// moveTree 移动目录或文件到dst，跨设备时先复制再删除源文件
+func moveTree(src, dst string) error {
+	err := os.Rename(src, dst)
+	if err == nil || !isCrossDevice(err) {
+		return err
+	}
+
+	if err := copyTree(src, dst, LinkNoFollow); err != nil {
+		os.RemoveAll(dst)
+		return err
+	}
+	return os.RemoveAll(src)
+}
@@ -0,0 +1,138 @@
+package snake
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// charsetDeclarations 文件中声明编码的位置，第二个分组为编码名称
+var charsetDeclarations = []*regexp.Regexp{
+	// <meta charset="gb2312"> 及 <meta http-equiv="Content-Type" content="text/html; charset=gb2312"> ...
+	regexp.MustCompile(`(?i)(<meta\s[^>]*?charset\s*=\s*["']?)([\w.:-]+)`),
+	// <?xml version="1.0" encoding="gbk"?> ...
+	regexp.MustCompile(`(?i)(<\?xml\s[^>]*?encoding\s*=\s*["'])([\w.:-]+)`),
+	// header('Content-Type: text/html; charset=gb2312') ...
+	regexp.MustCompile(`(?i)(header\s*\(\s*["']\s*Content-Type\s*:[^"']*?charset\s*=\s*)([\w.:-]+)`),
+	// @charset "gbk"; ...
+	regexp.MustCompile(`(?i)(@charset\s+["'])([\w.:-]+)`),
+}
+
+// DeclaredCharset 返回HTML meta、XML声明、PHP header() 或CSS @charset中声明的编码
+func (t *SnakeString) DeclaredCharset() (string, bool) {
+	return declaredCharset(t.Input)
+}
+
+// RewriteCharset 将HTML meta、XML声明、PHP header() 及CSS @charset中声明的编码修改为charset
+// 例子：
+// snake.String(`<meta charset="gb2312">`).RewriteCharset("UTF-8").Get() // <meta charset="utf-8">
+func (t *SnakeString) RewriteCharset(charset string) *SnakeString {
+	t.Input = rewriteCharsetDeclarations(t.Input, charset)
+	return t
+}
+
+// declaredCharset 返回第一个声明的编码
+func declaredCharset(src string) (string, bool) {
+	pos, charset := -1, ""
+	for _, re := range charsetDeclarations {
+		if m := re.FindStringSubmatchIndex(src); m != nil && (pos < 0 || m[0] < pos) {
+			pos, charset = m[0], src[m[4]:m[5]]
+		}
+	}
+	return strings.ToUpper(charset), pos >= 0
+}
+
+// rewriteCharsetDeclarations 修改所有声明的编码，保持原声明的大小写风格
+func rewriteCharsetDeclarations(src, charset string) string {
+	name := charsetDeclarationName(charset)
+	for _, re := range charsetDeclarations {
+		src = re.ReplaceAllStringFunc(src, func(s string) string {
+			m := re.FindStringSubmatch(s)
+			v := strings.ToLower(name)
+			if m[2] == strings.ToUpper(m[2]) {
+				v = strings.ToUpper(name)
+			}
+			return m[1] + v + s[len(m[0]):]
+		})
+	}
+	return src
+}
+
+// charsetDeclarationMismatch 判断文件中声明的编码是否与charset不一致
+func charsetDeclarationMismatch(src, charset string) bool {
+	name := charsetDeclarationName(charset)
+	for _, re := range charsetDeclarations {
+		for _, m := range re.FindAllStringSubmatch(src, -1) {
+			if !strings.EqualFold(m[2], name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// singleByteCharset 判断是否为ISO-8859-x、WINDOWS-125x等单字节编码
+func singleByteCharset(charset string) bool {
+	_, ok := getEncoding(charset).(*charmap.Charmap)
+	return ok
+}
+
+// charsetDeclarationName 写入声明中的编码名称，声明中不区分是否带BOM
+func charsetDeclarationName(charset string) string {
+	if strings.EqualFold(charset, CharsetUTF8BOM) {
+		return "UTF-8"
+	}
+	return charset
+}
+
+// declaredCharsetConfidence 检测结果的可信度低于该值，或与其他编码的差距小于 declaredCharsetMargin 时才使用文件中声明的编码
+const (
+	declaredCharsetConfidence = 0.6
+	declaredCharsetMargin     = 0.1
+)
+
+// decodeDeclaredText 按文件中声明的编码解码
+// 仅在内容不是UTF-8且没有BOM、声明的编码与检测结果不同，且检测结果不可靠时使用，解码出现替换字符时视为声明无效。
+// 单字节编码几乎可以解码任意内容，声明为单字节编码时不会推翻可信度足够的多字节编码检测结果。
+func decodeDeclaredText(data []byte, detected string) (string, string, bool) {
+	if utf8.Valid(data) {
+		return "", "", false
+	}
+	for _, v := range textBOMs {
+		if bytes.HasPrefix(data, v.bom) {
+			return "", "", false
+		}
+	}
+
+	declared, ok := declaredCharset(string(data))
+	if !ok || sameCharset(declared, detected) || getEncoding(declared) == nil {
+		return "", "", false
+	}
+
+	c := DetectCharset(data)
+	if len(c) > 0 && c[0].Confidence >= declaredCharsetConfidence {
+		if singleByteCharset(declared) && !singleByteCharset(c[0].Charset) {
+			return "", "", false
+		}
+		// 与同族编码（例如GBK与GB18030）之外的下一个候选比较 ...
+		confident := true
+		for _, v := range c[1:] {
+			if !sameCharset(v.Charset, c[0].Charset) {
+				confident = c[0].Confidence-v.Confidence >= declaredCharsetMargin
+				break
+			}
+		}
+		if confident {
+			return "", "", false
+		}
+	}
+
+	src, err := decodeTextAs(data, declared)
+	if err != nil || strings.ContainsRune(src, utf8.RuneError) {
+		return "", "", false
+	}
+	return src, declared, true
+}
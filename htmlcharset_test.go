@@ -0,0 +1,60 @@
+package snake
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// transcodeTestPage 写入按charset编码的页面并按HTML模式转为UTF-8，返回转换结果及转换后的内容
+func transcodeTestPage(t *testing.T, body, charset, declared string) (TranscodeResult, string) {
+	t.Helper()
+	text, err := String(body).ToCharset(charset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := filepath.Join(t.TempDir(), "index.html")
+	page := `<html><head><meta charset="` + declared + `"></head><body><p>` + text + `</p></body></html>`
+	if err := os.WriteFile(p, []byte(page), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := FS(p).Transcode("", "UTF-8", TranscodeOptions{HTML: true, NoBackup: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 1 {
+		t.Fatalf("Transcode: got %d results, want 1", len(res))
+	}
+	out, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res[0], string(out)
+}
+
+func TestTranscodeHTMLWrongDeclaration(t *testing.T) {
+	// 可信的GBK检测结果不会被声明的单字节编码推翻 ...
+	r, out := transcodeTestPage(t, "织梦内容管理系统，欢迎使用！", "GBK", "iso-8859-1")
+	if r.Status != TranscodeConverted || r.From != "GBK" {
+		t.Errorf("got %v from %q, want converted from GBK", r.Status, r.From)
+	}
+	if !strings.Contains(out, "织梦内容管理系统，欢迎使用！") {
+		t.Errorf("content corrupted: %q", out)
+	}
+	if !strings.Contains(strings.ToLower(out), `<meta charset="utf-8">`) {
+		t.Errorf("declaration not rewritten: %q", out)
+	}
+}
+
+func TestTranscodeHTMLAmbiguousDeclaration(t *testing.T) {
+	// 内容过短检测结果不可靠时按声明的编码解码 ...
+	r, out := transcodeTestPage(t, "中文", "EUC-JP", "euc-jp")
+	if r.Status != TranscodeConverted || !sameCharset(r.From, "EUC-JP") {
+		t.Errorf("got %v from %q, want converted from EUC-JP", r.Status, r.From)
+	}
+	if !strings.Contains(out, "<p>中文</p>") {
+		t.Errorf("content corrupted: %q", out)
+	}
+}
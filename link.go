@@ -0,0 +1,129 @@
+package snake
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// LinkPolicy 目录遍历时对符号链接的处理方式
+type LinkPolicy int
+
+const (
+	LinkDefault  LinkPolicy = iota // 各操作的默认方式：Cp 跟随符号链接，其余操作不跟随
+	LinkNoFollow                   // 不跟随符号链接，链接本身作为一个条目处理
+	LinkFollow                     // 跟随符号链接，进入链接指向的目录并检测循环链接
+)
+
+// Links 返回使用指定符号链接处理方式的新FileSystem
+// 影响 Find、Cp 及 Tarlib、Ziplib 的 AddDir；Rm 始终只删除链接本身。
+// 例子：
+// snake.FS("./templets").Links(snake.LinkFollow).Find("*.htm")
+func (sk *snakeFileSystem) Links(policy LinkPolicy) FileSystem {
+	n := sk.derive(sk.Path).(*snakeFileSystem)
+	n.links = policy
+	return n
+}
+
+// LinkPolicy 返回当前的符号链接处理方式
+func (sk *snakeFileSystem) LinkPolicy() LinkPolicy {
+	return sk.links
+}
+
+// Lstat 获取文件信息，不跟随符号链接
+func (sk *snakeFileSystem) Lstat() (os.FileInfo, error) {
+	return os.Lstat(sk.Path)
+}
+
+// IsSymlink 判断是否为符号链接
+func (sk *snakeFileSystem) IsSymlink(dst ...string) bool {
+	if i, err := os.Lstat(sk.pathdst(dst...)); err == nil {
+		return i.Mode()&os.ModeSymlink != 0
+	}
+	return false
+}
+
+// Lexist 判断目录、文件或符号链接是否存在，指向不存在目标的符号链接也返回true
+func (sk *snakeFileSystem) Lexist(dst ...string) bool {
+	_, err := os.Lstat(sk.pathdst(dst...))
+	return err == nil
+}
+
+// Readlink 返回符号链接指向的路径
+func (sk *snakeFileSystem) Readlink() (string, error) {
+	return os.Readlink(sk.Path)
+}
+
+// Symlink 在当前路径创建指向target的符号链接
+// 例子：
+// snake.FS("uploads/latest").Symlink("2021/07")
+func (sk *snakeFileSystem) Symlink(target string) bool {
+	if !FS(sk.Dir()).Exist() {
+		sk.MkDir(sk.Dir())
+	}
+	return os.Symlink(target, sk.Path) == nil
+}
+
+// Hardlink 在当前路径创建指向target的硬链接
+func (sk *snakeFileSystem) Hardlink(target string) bool {
+	if !FS(sk.Dir()).Exist() {
+		sk.MkDir(sk.Dir())
+	}
+	return os.Link(target, sk.Path) == nil
+}
+
+// walkTree 遍历目录，policy为LinkFollow时进入符号链接指向的目录，
+// 通过比较祖先目录检测循环链接，形成循环的链接按不跟随处理。
+// fn返回filepath.SkipDir时跳过该目录。
+func walkTree(root string, policy LinkPolicy, fn func(p string, info os.FileInfo) error) error {
+	info, err := os.Lstat(root)
+	if err != nil {
+		return err
+	}
+	return walkTreeItem(root, info, policy, nil, fn)
+}
+
+// walkTreeItem 递归遍历单个条目
+func walkTreeItem(p string, info os.FileInfo, policy LinkPolicy, parents []os.FileInfo, fn func(p string, info os.FileInfo) error) error {
+	if policy == LinkFollow && info.Mode()&os.ModeSymlink != 0 {
+		if target, err := os.Stat(p); err == nil {
+			looped := false
+			for _, v := range parents {
+				if os.SameFile(v, target) {
+					looped = true
+					break
+				}
+			}
+			if !looped {
+				info = target
+			}
+		}
+	}
+
+	err := fn(p, info)
+	if !info.IsDir() {
+		return err
+	}
+	if err == filepath.SkipDir {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(p)
+	if err != nil {
+		return err
+	}
+
+	parents = append(parents, info)
+	for _, entry := range entries {
+		child, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		if err := walkTreeItem(filepath.Join(p, entry.Name()), child, policy, parents, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,271 @@
+package snake
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrLockTimeout 在指定时间内未能获取文件锁
+var ErrLockTimeout = errors.New("lock timeout")
+
+// ErrLockUpgrade 同一协程持有共享锁时不能再获取排它锁
+var ErrLockUpgrade = errors.New("cannot upgrade shared lock to exclusive")
+
+var (
+	errLockWouldBlock  = errors.New("lock would block")
+	errLockUnsupported = errors.New("lock unsupported")
+)
+
+// lockRetryInterval 获取锁失败后的重试间隔
+const lockRetryInterval = 10 * time.Millisecond
+
+// staleLockAge 其他主机创建的独占锁文件超过该时间视为失效
+const staleLockAge = 10 * time.Minute
+
+// FileLock 文件锁，锁定的是与文件同目录的"文件名.lock"，
+// 因此可以配合原子替换（先写临时文件再改名）使用。
+// Linux等系统使用flock，不支持flock的系统或文件系统使用独占创建锁文件"文件名.lock.excl"的方式，此时RLock等同于Lock，
+// 锁文件中记录持有者的PID、主机名及时间，持有者进程已退出或其他主机的锁超过10分钟时视为失效并删除。
+// 同一协程内重复加锁（例如 WithLock 中通过 AutoLock 写入同一文件）直接复用已持有的锁，释放以最外层为准。
+// "文件名.lock"在释放后保留，删除后其他进程可能锁定不同的文件，导致互斥失效。
+type FileLock struct {
+	path     string
+	file     *os.File
+	fallback bool
+	held     heldLockKey
+	nested   bool
+}
+
+// heldLockKey 当前进程持有的锁，按锁文件绝对路径及协程区分
+type heldLockKey struct {
+	path string
+	goid uint64
+}
+
+// heldLocks 当前进程持有的锁，值为是否为共享锁
+var heldLocks = struct {
+	sync.Mutex
+	m map[heldLockKey]bool
+}{m: map[heldLockKey]bool{}}
+
+// Lock 获取排它锁，阻塞直到成功
+// 例子：
+// l, err := snake.FS("data/cache/index.htm").Lock()
+// defer l.Unlock()
+func (sk *snakeFileSystem) Lock() (*FileLock, error) {
+	return acquireLock(sk.lockPath(), false, -1)
+}
+
+// RLock 获取共享锁，阻塞直到成功
+func (sk *snakeFileSystem) RLock() (*FileLock, error) {
+	return acquireLock(sk.lockPath(), true, -1)
+}
+
+// TryLock 在timeout时间内尝试获取排它锁，超时返回ErrLockTimeout，timeout为0时只尝试一次
+func (sk *snakeFileSystem) TryLock(timeout time.Duration) (*FileLock, error) {
+	if timeout < 0 {
+		timeout = 0
+	}
+	return acquireLock(sk.lockPath(), false, timeout)
+}
+
+// WithLock 获取排它锁后执行fn，结束后释放锁
+// fn中在同一协程内通过 AutoLock 写入该文件时复用已持有的锁。
+func (sk *snakeFileSystem) WithLock(fn func() error) error {
+	l, err := sk.Lock()
+	if err != nil {
+		return err
+	}
+	defer l.Unlock()
+	return fn()
+}
+
+// AutoLock 返回写入时自动加锁的新FileSystem
+// 开启后 Write、ByteWriter 及 AtomicWriter 会在写入期间持有排它锁。
+func (sk *snakeFileSystem) AutoLock(on ...bool) FileSystem {
+	n := sk.derive(sk.Path).(*snakeFileSystem)
+	n.autolock = len(on) == 0 || on[0]
+	return n
+}
+
+// Unlock 释放文件锁
+func (l *FileLock) Unlock() error {
+	if l == nil || l.nested || l.file == nil {
+		return nil
+	}
+	f := l.file
+	l.file = nil
+
+	heldLocks.Lock()
+	delete(heldLocks.m, l.held)
+	heldLocks.Unlock()
+
+	if l.fallback {
+		f.Close()
+		return os.Remove(l.path)
+	}
+	funlock(f)
+	return f.Close()
+}
+
+// lockPath 返回锁文件路径
+func (sk *snakeFileSystem) lockPath() string {
+	return sk.Get() + ".lock"
+}
+
+// autoLock 开启自动加锁时获取排它锁，返回释放函数
+func (sk *snakeFileSystem) autoLock() (func(), error) {
+	if !sk.autolock {
+		return func() {}, nil
+	}
+	l, err := sk.Lock()
+	if err != nil {
+		return func() {}, err
+	}
+	return func() { l.Unlock() }, nil
+}
+
+// acquireLock 获取文件锁，timeout小于0时阻塞直到成功
+func acquireLock(path string, shared bool, timeout time.Duration) (*FileLock, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	held := heldLockKey{path: abs, goid: goid()}
+
+	// 同一协程已持有该锁时复用，否则在同一进程内再次加锁会永久阻塞 ...
+	heldLocks.Lock()
+	if heldShared, ok := heldLocks.m[held]; ok {
+		heldLocks.Unlock()
+		if heldShared && !shared {
+			return nil, ErrLockUpgrade
+		}
+		return &FileLock{path: path, held: held, nested: true}, nil
+	}
+	heldLocks.Unlock()
+
+	l, err := acquireFileLock(path, shared, timeout)
+	if err != nil {
+		return nil, err
+	}
+	l.held = held
+	heldLocks.Lock()
+	heldLocks.m[held] = shared && !l.fallback
+	heldLocks.Unlock()
+	return l, nil
+}
+
+// acquireFileLock 通过flock或锁文件获取锁
+func acquireFileLock(path string, shared bool, timeout time.Duration) (*FileLock, error) {
+	if !FS(FS(path).Dir()).Exist() {
+		FS(path).MkDir(FS(path).Dir())
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		err := flock(f, shared, timeout >= 0)
+		if err == nil {
+			return &FileLock{path: path, file: f}, nil
+		}
+		if err == errLockUnsupported {
+			f.Close()
+			return acquireFallbackLock(path+".excl", timeout)
+		}
+		if err != errLockWouldBlock {
+			f.Close()
+			return nil, err
+		}
+		if time.Now().After(deadline) {
+			f.Close()
+			return nil, ErrLockTimeout
+		}
+		time.Sleep(lockRetryInterval)
+	}
+}
+
+// acquireFallbackLock 通过独占创建锁文件的方式获取锁
+func acquireFallbackLock(path string, timeout time.Duration) (*FileLock, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			host, _ := os.Hostname()
+			if _, err := fmt.Fprintf(f, "%d\n%s\n%d\n", os.Getpid(), host, time.Now().UnixNano()); err != nil {
+				f.Close()
+				os.Remove(path)
+				return nil, err
+			}
+			return &FileLock{path: path, file: f, fallback: true}, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+		if breakStaleLock(path) {
+			continue
+		}
+		if timeout >= 0 && time.Now().After(deadline) {
+			return nil, ErrLockTimeout
+		}
+		time.Sleep(lockRetryInterval)
+	}
+}
+
+// breakStaleLock 锁文件的持有者已失效时删除锁文件，返回是否已删除
+// 持有者为本机进程时判断进程是否存在，为其他主机时按创建时间判断。
+func breakStaleLock(path string) bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) < 3 {
+		// 持有者可能刚创建文件尚未写入，按修改时间判断 ...
+		info, err := os.Stat(path)
+		if err != nil || time.Since(info.ModTime()) < staleLockAge {
+			return false
+		}
+	} else {
+		pid, _ := strconv.Atoi(fields[0])
+		created, _ := strconv.ParseInt(fields[2], 10, 64)
+		host, _ := os.Hostname()
+		if fields[1] == host {
+			if pid <= 0 || processAlive(pid) {
+				return false
+			}
+		} else if time.Since(time.Unix(0, created)) < staleLockAge {
+			return false
+		}
+	}
+
+	// 删除前确认锁文件未被其他进程替换 ...
+	if now, err := os.ReadFile(path); err != nil || !bytes.Equal(now, data) {
+		return false
+	}
+	return os.Remove(path) == nil
+}
+
+// goid 返回当前协程的ID，用于识别同一协程内的重复加锁
+func goid() uint64 {
+	buf := make([]byte, 64)
+	buf = buf[:runtime.Stack(buf, false)]
+	// 格式：goroutine 18 [running]: ...
+	buf = bytes.TrimPrefix(buf, []byte("goroutine "))
+	if i := bytes.IndexByte(buf, ' '); i > 0 {
+		buf = buf[:i]
+	}
+	id, _ := strconv.ParseUint(string(buf), 10, 64)
+	return id
+}
@@ -0,0 +1,48 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+// +build linux darwin dragonfly freebsd netbsd openbsd
+
+package snake
+
+import (
+	"os"
+	"syscall"
+)
+
+// flock 通过flock加锁，nonblock为true时无法立即获取则返回errLockWouldBlock
+func flock(f *os.File, shared, nonblock bool) error {
+	how := syscall.LOCK_EX
+	if shared {
+		how = syscall.LOCK_SH
+	}
+	if nonblock {
+		how |= syscall.LOCK_NB
+	}
+
+	for {
+		err := syscall.Flock(int(f.Fd()), how)
+		switch err {
+		case nil:
+			return nil
+		case syscall.EINTR:
+			continue
+		case syscall.EWOULDBLOCK:
+			return errLockWouldBlock
+		}
+		// 部分系统中ENOTSUP与EOPNOTSUPP为同一个值，不能同时写在case中 ...
+		if err == syscall.ENOSYS || err == syscall.ENOTSUP || err == syscall.EOPNOTSUPP {
+			return errLockUnsupported
+		}
+		return err
+	}
+}
+
+// processAlive 判断本机进程是否存在
+func processAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || err == syscall.EPERM
+}
+
+// funlock 释放flock锁
+func funlock(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+}
@@ -0,0 +1,26 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd
+// +build !linux,!darwin,!dragonfly,!freebsd,!netbsd,!openbsd
+
+package snake
+
+import "os"
+
+// flock 当前系统不支持flock，使用锁文件方式
+func flock(f *os.File, shared, nonblock bool) error {
+	return errLockUnsupported
+}
+
+// processAlive 判断本机进程是否存在，无法判断时视为存在
+func processAlive(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	p.Release()
+	return true
+}
+
+// funlock 当前系统不支持flock
+func funlock(f *os.File) error {
+	return nil
+}
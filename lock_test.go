@@ -0,0 +1,88 @@
+package snake
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWithLockAutoLockReentrant(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "index.htm")
+	f := FS(p)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- f.WithLock(func() error {
+			// 同一协程内通过 AutoLock 写入同一文件 ...
+			if err := f.AutoLock().AtomicWriter([]byte("ok")); err != nil {
+				return err
+			}
+
+			// 其他协程仍然无法获取锁 ...
+			res := make(chan error, 1)
+			go func() {
+				l, err := f.TryLock(0)
+				l.Unlock()
+				res <- err
+			}()
+			if err := <-res; err != ErrLockTimeout {
+				return fmt.Errorf("TryLock from another goroutine: got %v, want ErrLockTimeout", err)
+			}
+			return nil
+		})
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("WithLock with AutoLock write deadlocked")
+	}
+
+	// 最外层释放后其他协程可以获取锁 ...
+	l, err := f.TryLock(0)
+	if err != nil {
+		t.Fatalf("TryLock after WithLock: %v", err)
+	}
+	l.Unlock()
+}
+
+func TestFallbackLockStale(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "index.htm.lock.excl")
+	host, _ := os.Hostname()
+
+	// 持有者进程已退出 ...
+	if err := os.WriteFile(p, []byte(fmt.Sprintf("%d\n%s\n%d\n", 1<<30, host, time.Now().UnixNano())), 0644); err != nil {
+		t.Fatal(err)
+	}
+	l, err := acquireFallbackLock(p, time.Second)
+	if err != nil {
+		t.Fatalf("stale lock of dead process not broken: %v", err)
+	}
+	l.Unlock()
+
+	// 其他主机的锁未超时 ...
+	if err := os.WriteFile(p, []byte(fmt.Sprintf("%d\n%s\n%d\n", 1, host+".other", time.Now().UnixNano())), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := acquireFallbackLock(p, 50*time.Millisecond); err != ErrLockTimeout {
+		t.Fatalf("fresh lock of other host: got %v, want ErrLockTimeout", err)
+	}
+
+	// 其他主机的锁已超时 ...
+	if err := os.WriteFile(p, []byte(fmt.Sprintf("%d\n%s\n%d\n", 1, host+".other", time.Now().Add(-2*staleLockAge).UnixNano())), 0644); err != nil {
+		t.Fatal(err)
+	}
+	l, err = acquireFallbackLock(p, time.Second)
+	if err != nil {
+		t.Fatalf("expired lock of other host not broken: %v", err)
+	}
+	l.Unlock()
+	if _, err := os.Stat(p); !os.IsNotExist(err) {
+		t.Errorf("lock file not removed on Unlock: %v", err)
+	}
+}
@@ -0,0 +1,400 @@
+package snake
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// sniffLen 内容检测读取的字节数
+const sniffLen = 3072
+
+// MimeInfo 文件类型检测结果
+type MimeInfo struct {
+	Extension string // 根据扩展名获取的类型
+	Content   string // 根据文件内容获取的类型，文本类型包含charset参数
+	Mismatch  bool   // 扩展名与文件内容不一致
+}
+
+// magicRule 文件头特征，check不为nil时进一步校验文件结构，避免较短的特征误判普通文本
+type magicRule struct {
+	offset int
+	magic  []byte
+	mime   string
+	check  func(data []byte) bool
+}
+
+// magicRules 常见文件头特征，按顺序匹配
+var magicRules = []magicRule{
+	// 图片 ...
+	{0, []byte("\xFF\xD8\xFF"), "image/jpeg", nil},
+	{0, []byte("\x89PNG\r\n\x1A\n"), "image/png", nil},
+	{0, []byte("GIF87a"), "image/gif", nil},
+	{0, []byte("GIF89a"), "image/gif", nil},
+	{0, []byte("\x00\x00\x01\x00"), "image/x-icon", nil},
+	{0, []byte("II*\x00"), "image/tiff", nil},
+	{0, []byte("MM\x00*"), "image/tiff", nil},
+	{0, []byte("8BPS"), "image/vnd.adobe.photoshop", nil},
+	// 压缩包 ...
+	{0, []byte("PK\x03\x04"), "application/zip", nil},
+	{0, []byte("PK\x05\x06"), "application/zip", nil},
+	{0, []byte("\x1F\x8B"), "application/gzip", nil},
+	{0, []byte("BZh"), "application/x-bzip2", checkBzip2},
+	{0, []byte("7z\xBC\xAF\x27\x1C"), "application/x-7z-compressed", nil},
+	{0, []byte("Rar!\x1A\x07"), "application/x-rar-compressed", nil},
+	{0, []byte("\xFD7zXZ\x00"), "application/x-xz", nil},
+	{257, []byte("ustar"), "application/x-tar", nil},
+	// 文档 ...
+	{0, []byte("%PDF-"), "application/pdf", nil},
+	{0, []byte("{\\rtf"), "text/rtf", nil},
+	{0, []byte("\xD0\xCF\x11\xE0\xA1\xB1\x1A\xE1"), "application/msword", nil},
+	// 字体 ...
+	{0, []byte("wOFF"), "font/woff", nil},
+	{0, []byte("wOF2"), "font/woff2", nil},
+	{0, []byte("OTTO"), "font/otf", nil},
+	// 音频、视频 ...
+	{0, []byte("ID3"), "audio/mpeg", checkID3},
+	{0, []byte("OggS"), "audio/ogg", nil},
+	{0, []byte("fLaC"), "audio/x-flac", nil},
+	{0, []byte("FLV\x01"), "video/x-flv", nil},
+	{0, []byte("\x1A\x45\xDF\xA3"), "video/webm", nil},
+	// 可执行文件 ...
+	{0, []byte("MZ"), "application/x-msdownload", checkPE},
+	{0, []byte("\x7FELF"), "application/x-executable", nil},
+	{0, []byte("\x00asm"), "application/wasm", nil},
+	{0, []byte("\xCA\xFE\xBA\xBE"), "application/java-vm", nil},
+	{0, []byte("FWS"), "application/x-shockwave-flash", checkSWF},
+	{0, []byte("CWS"), "application/x-shockwave-flash", checkSWF},
+}
+
+// scriptMimeTypes 脚本类型，内容为脚本时只允许使用对应的扩展名
+var scriptMimeTypes = map[string][]string{
+	"application/x-httpd-php": {"php", "php3", "php4", "php5", "php7", "phtml", "inc"},
+	"application/x-sh":        {"sh", "bash"},
+	"application/x-perl":      {"pl", "pm", "cgi"},
+	"text/x-python":           {"py", "cgi"},
+}
+
+// containerMimeTypes 基于容器格式的文件，扩展名类型以前缀匹配
+var containerMimeTypes = map[string][]string{
+	"application/zip": {
+		"application/vnd.openxmlformats-officedocument.",
+		"application/vnd.oasis.opendocument.",
+		"application/vnd.android.package-archive",
+		"application/java-archive",
+		"application/epub+zip",
+		"application/vnd.",
+		"application/x-xpinstall",
+	},
+	"application/msword": {
+		"application/vnd.ms-",
+		"application/x-msdownload",
+	},
+	"video/webm": {
+		"video/x-matroska",
+		"audio/webm",
+	},
+	"audio/ogg": {
+		"video/ogg",
+	},
+	"video/mp4": {
+		"audio/mp4",
+		"video/x-m4v",
+	},
+}
+
+// ContentType 根据文件内容获取MimeTypes
+// 文本类型会附带通过Charset()检测的字符集，例如：text/plain; charset=gbk
+func (sk *snakeFileSystem) ContentType() string {
+	data, err := sniffPrefix(sk.Path)
+	if err != nil {
+		return ""
+	}
+	return sniffMime(data)
+}
+
+// sniffPrefix 读取文件开头用于内容检测的部分
+func sniffPrefix(p string) ([]byte, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	buf := make([]byte, sniffLen)
+	n, err := io.ReadFull(f, buf)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+	return buf[:n], nil
+}
+
+// MimeDetect 同时根据扩展名及文件内容获取MimeTypes，并判断两者是否一致
+// 上传检查时可通过Mismatch判断是否为伪装扩展名的文件，
+// 无论文件头是什么类型，内容中包含PHP代码或<script>而扩展名不是对应的脚本类型时均视为不一致。
+// 例子：
+// if snake.FS("upload/a.jpg").MimeDetect().Mismatch { ... }
+func (sk *snakeFileSystem) MimeDetect() MimeInfo {
+	info := MimeInfo{Extension: sk.MimeTypes()}
+	data, err := sniffPrefix(sk.Path)
+	if err != nil {
+		return info
+	}
+	info.Content = sniffMime(data)
+
+	ext := String(sk.Ext()).Trim(".").ToLower().Get()
+	info.Mismatch = !mimeCompatible(ext, info.Extension, info.Content) || !embeddedScriptAllowed(ext, info.Extension, data)
+	return info
+}
+
+// ExtensionsFor 根据MimeTypes反向获取扩展名列表
+// 例子：
+// snake.ExtensionsFor("image/jpeg")
+// 返回：[]string{"jpe", "jpeg", "jpg"}
+func ExtensionsFor(mime string) []string {
+	return mimeTypes.Extensions(mime)
+}
+
+// sniffMime 根据内容获取MimeTypes
+func sniffMime(data []byte) string {
+	if len(data) == 0 {
+		return "text/plain"
+	}
+
+	for _, v := range magicRules {
+		if len(data) >= v.offset+len(v.magic) && bytes.Equal(data[v.offset:v.offset+len(v.magic)], v.magic) && (v.check == nil || v.check(data)) {
+			return v.mime
+		}
+	}
+
+	if mime := sniffContainer(data); mime != "" {
+		return mime
+	}
+
+	// MP3 无ID3标签时以帧同步字节开头 ...
+	if len(data) > 1 && data[0] == 0xFF && data[1]&0xE0 == 0xE0 {
+		return "audio/mpeg"
+	}
+
+	if !isText(data) {
+		return "application/octet-stream"
+	}
+
+	mime := sniffText(data)
+	if strings.HasPrefix(mime, "text/") {
+		if charset, ok := String(string(data)).Charset(); ok {
+			mime += "; charset=" + strings.ToLower(charset)
+		}
+	}
+	return mime
+}
+
+// checkPE e_lfanew（0x3C处）指向的位置为"PE\0\0"
+func checkPE(data []byte) bool {
+	if len(data) < 0x40 {
+		return false
+	}
+	offset := int(binary.LittleEndian.Uint32(data[0x3c:]))
+	return offset >= 0x40 && offset+4 <= len(data) && bytes.Equal(data[offset:offset+4], []byte("PE\x00\x00"))
+}
+
+// checkBzip2 块大小为1～9，之后为数据块或结束块的特征
+func checkBzip2(data []byte) bool {
+	if len(data) < 10 || data[3] < '1' || data[3] > '9' {
+		return false
+	}
+	magic := data[4:10]
+	return bytes.Equal(magic, []byte("\x31\x41\x59\x26\x53\x59")) || bytes.Equal(magic, []byte("\x17\x72\x45\x38\x50\x90"))
+}
+
+// checkID3 主版本为2～4，修订号不为0xFF，未定义的标志位为0，标签长度每字节最高位为0
+func checkID3(data []byte) bool {
+	if len(data) < 10 || data[3] < 2 || data[3] > 4 || data[4] == 0xff || data[5]&0x0f != 0 {
+		return false
+	}
+	for _, b := range data[6:10] {
+		if b >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
+
+// checkSWF 版本号不超过50，文件长度不超过512MB，纯文本的这4个字节组成的长度均超过该值
+func checkSWF(data []byte) bool {
+	if len(data) < 8 || data[3] == 0 || data[3] > 50 {
+		return false
+	}
+	size := binary.LittleEndian.Uint32(data[4:8])
+	return size >= 8 && size < 0x20000000
+}
+
+// sniffContainer 判断BMP、RIFF及ISO媒体容器格式
+func sniffContainer(data []byte) string {
+	// BMP 文件头第6～9字节为保留字段，必须为0 ...
+	if len(data) >= 14 && bytes.Equal(data[:2], []byte("BM")) && bytes.Equal(data[6:10], []byte{0, 0, 0, 0}) {
+		return "image/x-ms-bmp"
+	}
+
+	if len(data) >= 12 && bytes.Equal(data[:4], []byte("RIFF")) {
+		switch string(data[8:12]) {
+		case "WEBP":
+			return "image/webp"
+		case "WAVE":
+			return "audio/x-wav"
+		case "AVI ":
+			return "video/x-msvideo"
+		}
+	}
+
+	if len(data) >= 12 && bytes.Equal(data[4:8], []byte("ftyp")) {
+		switch brand := string(data[8:12]); {
+		case brand == "heic" || brand == "heix" || brand == "mif1":
+			return "image/heic"
+		case brand == "avif":
+			return "image/avif"
+		case brand == "qt  ":
+			return "video/quicktime"
+		case strings.HasPrefix(brand, "M4A"):
+			return "audio/x-m4a"
+		default:
+			return "video/mp4"
+		}
+	}
+	return ""
+}
+
+// sniffText 根据文本内容获取脚本、标记语言类型
+func sniffText(data []byte) string {
+	text := bytes.TrimLeft(bytes.TrimPrefix(data, []byte("\xEF\xBB\xBF")), " \t\r\n")
+	lower := bytes.ToLower(text)
+
+	switch {
+	case bytes.HasPrefix(lower, []byte("<?php")), bytes.HasPrefix(lower, []byte("<?=")):
+		return "application/x-httpd-php"
+	case bytes.HasPrefix(text, []byte("#!")):
+		line := lower
+		if i := bytes.IndexByte(line, '\n'); i > 0 {
+			line = line[:i]
+		}
+		switch {
+		case bytes.Contains(line, []byte("php")):
+			return "application/x-httpd-php"
+		case bytes.Contains(line, []byte("python")):
+			return "text/x-python"
+		case bytes.Contains(line, []byte("perl")):
+			return "application/x-perl"
+		default:
+			return "application/x-sh"
+		}
+	case bytes.HasPrefix(lower, []byte("<svg")):
+		return "image/svg+xml"
+	case bytes.HasPrefix(lower, []byte("<?xml")):
+		if bytes.Contains(lower, []byte("<svg")) {
+			return "image/svg+xml"
+		}
+		return "text/xml"
+	case bytes.HasPrefix(lower, []byte("<!doctype html")), bytes.HasPrefix(lower, []byte("<html")),
+		bytes.HasPrefix(lower, []byte("<head")), bytes.HasPrefix(lower, []byte("<body")):
+		return "text/html"
+	}
+
+	// 文件中任意位置出现PHP起始标记时，均视为PHP脚本 ...
+	if bytes.Contains(lower, []byte("<?php")) {
+		return "application/x-httpd-php"
+	}
+	return "text/plain"
+}
+
+// isText 判断内容是否为文本
+func isText(data []byte) bool {
+	// UTF-16、UTF-32 BOM ...
+	if bytes.HasPrefix(data, []byte("\xFF\xFE")) || bytes.HasPrefix(data, []byte("\xFE\xFF")) {
+		return true
+	}
+	for _, b := range data {
+		if b == 0x00 || (b < 0x20 && b != '\t' && b != '\n' && b != '\r' && b != '\f' && b != 0x1B) {
+			return false
+		}
+	}
+	if utf8.Valid(data) {
+		return true
+	}
+	_, ok := String(string(data)).Charset()
+	return ok
+}
+
+// embeddedScriptAllowed 判断内容中嵌入的脚本与扩展名是否一致
+// 例如 GIF89a<?php ... ?> 保存为 .gif 的图片木马，文件头为图片但包含PHP代码。
+func embeddedScriptAllowed(ext, byExt string, data []byte) bool {
+	php := "application/x-httpd-php"
+	if String(ext).ExistSlice(scriptMimeTypes[php]) || String(ext).ExistSlice(ExtensionsFor(php)) {
+		return true
+	}
+
+	lower := bytes.ToLower(data)
+	if bytes.Contains(lower, []byte("<?php")) || bytes.Contains(lower, []byte("<?=")) {
+		return false
+	}
+	if bytes.Contains(lower, []byte("<script")) {
+		byExt = mimeBase(byExt)
+		return byExt == "text/html" || byExt == "application/xhtml+xml" || strings.Contains(byExt, "javascript")
+	}
+	return true
+}
+
+// mimeCompatible 判断扩展名与文件内容类型是否一致
+func mimeCompatible(ext, byExt, byContent string) bool {
+	content := mimeBase(byContent)
+
+	// 脚本只允许使用对应的扩展名 ...
+	if exts, ok := scriptMimeTypes[content]; ok {
+		return String(ext).ExistSlice(exts) || String(ext).ExistSlice(ExtensionsFor(content))
+	}
+
+	// 无法通过内容判断类型或扩展名未知 ...
+	if content == "" || content == "application/octet-stream" || byExt == "" {
+		return true
+	}
+
+	if content == mimeBase(byExt) || String(ext).ExistSlice(ExtensionsFor(content)) {
+		return true
+	}
+
+	for _, prefix := range containerMimeTypes[content] {
+		if strings.HasPrefix(byExt, prefix) {
+			return true
+		}
+	}
+
+	// 纯文本内容可以对应任意文本类扩展名 ...
+	if content == "text/plain" || content == "text/html" || content == "text/xml" {
+		return isTextMime(byExt)
+	}
+	return false
+}
+
+// isTextMime 判断MimeTypes是否为文本类型
+func isTextMime(mime string) bool {
+	mime = mimeBase(mime)
+	if strings.HasPrefix(mime, "text/") {
+		return true
+	}
+	for _, v := range []string{"json", "javascript", "xml", "x-sh", "x-perl", "x-httpd-php", "x-csh", "x-tex", "x-latex"} {
+		if strings.Contains(mime, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// mimeBase 去除MimeTypes中的参数
+func mimeBase(mime string) string {
+	if i := strings.Index(mime, ";"); i >= 0 {
+		mime = mime[:i]
+	}
+	return strings.ToLower(strings.TrimSpace(mime))
+}
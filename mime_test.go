@@ -0,0 +1,64 @@
+package snake
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMimeDetectShortMagicText(t *testing.T) {
+	dir := t.TempDir()
+	for _, text := range []string{
+		"MZ is a nice prefix\n",
+		"BZh, said the bee\n",
+		"ID3 tags are documented here\n",
+		"FWS is short for Flash\n",
+		"CWS meeting at noon\n",
+	} {
+		p := filepath.Join(dir, "a.txt")
+		if err := os.WriteFile(p, []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+		info := FS(p).MimeDetect()
+		if !strings.HasPrefix(info.Content, "text/plain") || info.Mismatch {
+			t.Errorf("%q: got %+v, want text/plain without mismatch", text, info)
+		}
+	}
+}
+
+func TestSniffMimeStructure(t *testing.T) {
+	pe := make([]byte, 0x100)
+	copy(pe, "MZ")
+	binary.LittleEndian.PutUint32(pe[0x3c:], 0x80)
+	copy(pe[0x80:], "PE\x00\x00")
+
+	swf := []byte("FWS\x0a\x00\x10\x00\x00")
+	id3 := []byte("ID3\x03\x00\x00\x00\x00\x10\x00")
+	bz2 := []byte("BZh91AY&SY\x00\x00")
+
+	for _, c := range []struct {
+		data []byte
+		mime string
+	}{
+		{pe, "application/x-msdownload"},
+		{swf, "application/x-shockwave-flash"},
+		{id3, "audio/mpeg"},
+		{bz2, "application/x-bzip2"},
+	} {
+		if got := sniffMime(c.data); got != c.mime {
+			t.Errorf("sniffMime(%q): got %q, want %q", c.data[:4], got, c.mime)
+		}
+	}
+}
+
+func TestMimeDetectPolyglot(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "shell.gif")
+	if err := os.WriteFile(p, []byte("GIF89a<?php system($_GET[c]); ?>"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if info := FS(p).MimeDetect(); !info.Mismatch {
+		t.Errorf("GIF with embedded PHP: got %+v, want Mismatch", info)
+	}
+}
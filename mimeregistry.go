@@ -0,0 +1,217 @@
+package snake
+
+import (
+	"bufio"
+	"io"
+	"mime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// MimeRegistry 扩展名与MimeTypes对照表
+// 查找顺序：Override设置 > Load加载及内置数据 > 标准库mime包。
+type MimeRegistry struct {
+	mu        sync.RWMutex
+	types     map[string]string // 内置及加载的对照表
+	overrides map[string]string // 应用自定义覆盖
+	sync      bool              // 是否同步到标准库mime包
+}
+
+// mimeTypes 默认的MimeRegistry，FileSystem.MimeTypes()等方法均使用该对照表
+var mimeTypes = newDefaultMimeRegistry()
+
+// newDefaultMimeRegistry 根据内置对照表创建默认MimeRegistry
+func newDefaultMimeRegistry() *MimeRegistry {
+	r := NewMimeRegistry(builtinMimeTypes)
+	r.sync = true
+	return r
+}
+
+// NewMimeRegistry 新建MimeRegistry，可传入初始对照表
+func NewMimeRegistry(base ...map[string]string) *MimeRegistry {
+	r := &MimeRegistry{
+		types:     map[string]string{},
+		overrides: map[string]string{},
+	}
+	for _, m := range base {
+		for ext, v := range m {
+			r.types[normalizeExt(ext)] = v
+		}
+	}
+	return r
+}
+
+// DefaultMimeRegistry 返回默认的MimeRegistry
+func DefaultMimeRegistry() *MimeRegistry {
+	return mimeTypes
+}
+
+// LoadMimeTypes 将mime.types文件加载到默认的MimeRegistry
+func LoadMimeTypes(files ...FileSystem) error {
+	return mimeTypes.Load(files...)
+}
+
+// SetSync 设置是否将新增的对照关系同步到标准库mime.AddExtensionType
+func (r *MimeRegistry) SetSync(on bool) *MimeRegistry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sync = on
+	return r
+}
+
+// Get 根据扩展名获取MimeTypes，扩展名可带"."
+func (r *MimeRegistry) Get(ext string) string {
+	ext = normalizeExt(ext)
+	if ext == "" {
+		return ""
+	}
+
+	r.mu.RLock()
+	v, ok := r.overrides[ext]
+	if !ok {
+		v, ok = r.types[ext]
+	}
+	sync := r.sync
+	r.mu.RUnlock()
+
+	if ok {
+		return v
+	}
+
+	// 其它位置通过mime.AddExtensionType注册的类型 ...
+	if sync {
+		return mimeBase(mime.TypeByExtension("." + ext))
+	}
+	return ""
+}
+
+// Set 设置应用自定义的对照关系，优先于加载及内置数据
+func (r *MimeRegistry) Set(ext, typ string) error {
+	ext = normalizeExt(ext)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.sync {
+		if err := mime.AddExtensionType("."+ext, typ); err != nil {
+			return err
+		}
+	}
+	r.overrides[ext] = typ
+	return nil
+}
+
+// Override 批量设置应用自定义的对照关系
+func (r *MimeRegistry) Override(types map[string]string) error {
+	for ext, typ := range types {
+		if err := r.Set(ext, typ); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Remove 删除扩展名的自定义及加载数据
+func (r *MimeRegistry) Remove(ext string) {
+	ext = normalizeExt(ext)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.overrides, ext)
+	delete(r.types, ext)
+}
+
+// Extensions 根据MimeTypes反向获取扩展名列表
+func (r *MimeRegistry) Extensions(typ string) []string {
+	typ = mimeBase(typ)
+	var res []string
+	for ext, v := range r.Types() {
+		if mimeBase(v) == typ {
+			res = append(res, ext)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+// Types 返回合并后的对照表副本
+func (r *MimeRegistry) Types() map[string]string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	res := make(map[string]string, len(r.types)+len(r.overrides))
+	for ext, v := range r.types {
+		res[ext] = v
+	}
+	for ext, v := range r.overrides {
+		res[ext] = v
+	}
+	return res
+}
+
+// Load 加载Apache或nginx格式的mime.types文件
+// Apache格式：
+// image/jpeg					jpeg jpg jpe
+// nginx格式：
+// types { image/jpeg jpeg jpg; }
+func (r *MimeRegistry) Load(files ...FileSystem) error {
+	for _, f := range files {
+		// 通过FileSystem读取，沙箱等实现会校验路径 ...
+		fo, err := f.OpenFile()
+		if err != nil {
+			return err
+		}
+		b, err := io.ReadAll(fo)
+		fo.Close()
+		if err != nil {
+			return err
+		}
+		types := parseMimeTypes(string(b))
+
+		r.mu.Lock()
+		for ext, typ := range types {
+			if r.sync {
+				if err := mime.AddExtensionType("."+ext, typ); err != nil {
+					r.mu.Unlock()
+					return err
+				}
+			}
+			r.types[ext] = typ
+		}
+		r.mu.Unlock()
+	}
+	return nil
+}
+
+// parseMimeTypes 解析mime.types文件内容
+func parseMimeTypes(src string) map[string]string {
+	res := map[string]string{}
+	scanner := bufio.NewScanner(strings.NewReader(src))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+
+		// nginx 格式去除 types { } 及结尾分号 ...
+		line = strings.NewReplacer("{", " ", "}", " ", ";", " ").Replace(line)
+		fields := strings.Fields(line)
+		if len(fields) > 0 && fields[0] == "types" {
+			fields = fields[1:]
+		}
+		if len(fields) < 2 || !strings.Contains(fields[0], "/") {
+			continue
+		}
+
+		for _, ext := range fields[1:] {
+			if ext = normalizeExt(ext); ext != "" {
+				res[ext] = fields[0]
+			}
+		}
+	}
+	return res
+}
+
+// normalizeExt 统一扩展名格式：去除"."并转为小写
+func normalizeExt(ext string) string {
+	return String(ext).Trim(" ").Trim(".").ToLower().Get()
+}
@@ -0,0 +1,286 @@
+package snake
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+)
+
+// MojibakeStep 一次错误解码：按Encoding编码的内容被当作DecodedAs解码
+type MojibakeStep struct {
+	Encoding  string // 内容的实际编码
+	DecodedAs string // 错误使用的解码方式
+}
+
+// String 例如：UTF-8 decoded as WINDOWS-1252
+func (s MojibakeStep) String() string {
+	return s.Encoding + " decoded as " + s.DecodedAs
+}
+
+// mojibakeSteps 常见的错误解码，WINDOWS-1252 同时覆盖 ISO-8859-1
+var mojibakeSteps = []MojibakeStep{
+	{"UTF-8", "WINDOWS-1252"},
+	{"GBK", "WINDOWS-1252"},
+	{"BIG5", "WINDOWS-1252"},
+	{"UTF-8", "GBK"},
+}
+
+// mojibakeDepth 最多撤销的错误解码次数
+const mojibakeDepth = 3
+
+// cp1252High WINDOWS-1252 中 0x80-0x9F 对应的字符
+var cp1252High = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87, 'ˆ': 0x88,
+	'‰': 0x89, 'Š': 0x8a, '‹': 0x8b, 'Œ': 0x8c, 'Ž': 0x8e, '‘': 0x91, '’': 0x92, '“': 0x93,
+	'”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '˜': 0x98, '™': 0x99, 'š': 0x9a, '›': 0x9b,
+	'œ': 0x9c, 'ž': 0x9e, 'Ÿ': 0x9f,
+}
+
+// FixMojibake Function
+// 修复乱码：尝试撤销常见的错误解码（UTF-8与GBK互相误解、UTF-8或GBK被当作WINDOWS-1252/ISO-8859-1解码、多次UTF-8编码），
+// 结果比原文更像正常文字时替换当前内容，并按撤销顺序返回撤销的错误解码，无法修复时返回空列表 ...
+// 例子：
+// s, steps := snake.String("ÖÐÎÄ").FixMojibake() // 中文 [GBK decoded as WINDOWS-1252]
+func (t *SnakeString) FixMojibake() (string, []MojibakeStep) {
+	best, bestScore := t.Input, mojibakeScore(t.Input)
+	var bestSteps []MojibakeStep
+
+	type state struct {
+		s     string
+		steps []MojibakeStep
+	}
+	queue := []state{{s: t.Input}}
+	for depth := 0; depth < mojibakeDepth; depth++ {
+		var next []state
+		for _, cur := range queue {
+			for _, step := range mojibakeSteps {
+				s, strict, ok := undoMojibake(cur.s, step)
+				if !ok || s == cur.s {
+					continue
+				}
+				steps := append(append([]MojibakeStep{}, cur.steps...), step)
+				next = append(next, state{s: s, steps: steps})
+
+				// 还原出的字节完全符合UTF-8规则是很强的证据，但无法还原的字符不计入 ...
+				score := mojibakeScore(s)
+				if strict {
+					score += 1 - float64(strings.Count(s, string(utf8.RuneError)))/float64(utf8.RuneCountInString(s))
+				}
+				// 需要明显优于原文，避免误改正常文字 ...
+				if score > bestScore+0.1 || (bestSteps != nil && score > bestScore) {
+					best, bestScore, bestSteps = s, score, steps
+				}
+			}
+		}
+		queue = next
+	}
+
+	if bestSteps == nil {
+		return t.Input, []MojibakeStep{}
+	}
+	t.Input = best
+	return t.Input, bestSteps
+}
+
+// mojibakeMaxHoles 最多猜测的替换字符数
+const mojibakeMaxHoles = 16
+
+// mojibakeWindow 猜测丢失的字节时参与评分的前后字节数
+const mojibakeWindow = 6
+
+// undoMojibake 撤销一次错误解码：按DecodedAs编码还原字节，再按Encoding解码
+// 错误解码时变成替换字符（U+FFFD）的字节会根据前后内容猜测，无法还原的位置保留替换字符，
+// 其余位置出现不符合编码规则的字节时视为无法撤销。strict为true时还原的字节完全符合UTF-8规则。
+func undoMojibake(s string, step MojibakeStep) (string, bool, bool) {
+	data, holes, ok := mojibakeBytes(s, step.DecodedAs)
+	// 替换字符过多时只剩猜测，视为无法撤销 ...
+	if !ok || isASCII(data) || len(holes) > mojibakeMaxHoles || len(holes)*2 > utf8.RuneCountInString(s) {
+		return "", false, false
+	}
+
+	// 被当作多字节编码解码时，末尾不完整的字节通常已丢失 ...
+	lossy := step.DecodedAs != "WINDOWS-1252"
+	decode := func(b []byte, partial bool) (string, int) {
+		if step.Encoding == "UTF-8" {
+			if partial {
+				b = trimPartialRune(b)
+			}
+			invalid := 0
+			for i := 0; i < len(b); {
+				r, size := utf8.DecodeRune(b[i:])
+				if r == utf8.RuneError && size == 1 {
+					invalid++
+				}
+				i += size
+			}
+			return strings.ToValidUTF8(string(b), string(utf8.RuneError)), invalid
+		}
+		out, _ := getEncoding(step.Encoding).NewDecoder().Bytes(b)
+		rs := []rune(string(out))
+		if partial && len(rs) > 0 && rs[len(rs)-1] == utf8.RuneError {
+			rs = rs[:len(rs)-1]
+		}
+		invalid := 0
+		for _, r := range rs {
+			if r == utf8.RuneError {
+				invalid++
+			}
+		}
+		return string(rs), invalid
+	}
+
+	// 从后向前猜测丢失的字节，只解码前后若干字节评分 ...
+	var lost [][2]int
+	for i := len(holes) - 1; i >= 0; i-- {
+		h := holes[i]
+		var pick []byte
+		pickScore := -1e9
+		for _, c := range mojibakeHoleBytes(step.DecodedAs) {
+			start, end := h-mojibakeWindow, h+1+mojibakeWindow
+			if start < 0 {
+				start = 0
+			}
+			if end > len(data) {
+				end = len(data)
+			}
+			window := append(append(append([]byte{}, data[start:h]...), c...), data[h+1:end]...)
+			res, invalid := decode(window, false)
+			if score := mojibakeScore(res) - float64(invalid); score > pickScore {
+				pick, pickScore = c, score
+			}
+		}
+		data = append(data[:h], append(pick, data[h+1:]...)...)
+		for j := range lost {
+			lost[j][0] += len(pick) - 1
+			lost[j][1] += len(pick) - 1
+		}
+		if lossy {
+			lost = append(lost, [2]int{h, h + len(pick)})
+		}
+	}
+
+	res, invalid := decode(data, lossy)
+	if invalid > len(holes) {
+		return "", false, false
+	}
+
+	// 被当作多字节编码解码时丢失的字节无法确定，对应的字符保留替换字符 ...
+	if len(lost) > 0 && step.Encoding == "UTF-8" {
+		var buf strings.Builder
+		b := trimPartialRune(data)
+		for i := 0; i < len(b); {
+			r, size := utf8.DecodeRune(b[i:])
+			for _, v := range lost {
+				if i < v[1] && i+size > v[0] {
+					r = utf8.RuneError
+				}
+			}
+			buf.WriteRune(r)
+			i += size
+		}
+		res = buf.String()
+	}
+	strict := step.Encoding == "UTF-8" && invalid == 0 && len(trimPartialRune(data)) == len(data)
+	return res, strict, true
+}
+
+// mojibakeBytes 按错误使用的解码方式还原字节，返回替换字符所在的位置，该位置暂时填充一个字节
+func mojibakeBytes(s, charset string) ([]byte, []int, bool) {
+	var e encoding.Encoding
+	if charset != "WINDOWS-1252" {
+		if e = getEncoding(charset); e == nil {
+			return nil, nil, false
+		}
+	}
+
+	data := make([]byte, 0, len(s))
+	var holes []int
+	for _, r := range s {
+		if r == utf8.RuneError {
+			holes = append(holes, len(data))
+			data = append(data, 0x80)
+			continue
+		}
+		if e == nil {
+			// WINDOWS-1252 中未定义的 0x80-0x9F 按ISO-8859-1处理 ...
+			switch b, ok := cp1252High[r]; {
+			case ok:
+				data = append(data, b)
+			case r < 0x100:
+				data = append(data, byte(r))
+			default:
+				return nil, nil, false
+			}
+			continue
+		}
+		b, err := e.NewEncoder().Bytes([]byte(string(r)))
+		if err != nil {
+			return nil, nil, false
+		}
+		data = append(data, b...)
+	}
+	return data, holes, true
+}
+
+// mojibakeHoleBytes 错误解码时会变成替换字符的字节
+// WINDOWS-1252 为未定义的单个字节；GBK等双字节编码丢失一个或两个字节，无法确定具体内容，
+// 只需分别尝试UTF-8的后续字节及各长度的首字节，保证结构完整。
+func mojibakeHoleBytes(charset string) [][]byte {
+	if charset == "WINDOWS-1252" {
+		return [][]byte{{0x81}, {0x8d}, {0x8f}, {0x90}, {0x9d}}
+	}
+	kinds := []byte{0x80, 0xc2, 0xe1, 0xf1}
+	var res [][]byte
+	for _, a := range kinds {
+		res = append(res, []byte{a})
+		for _, b := range kinds {
+			res = append(res, []byte{a, b})
+		}
+	}
+	return res
+}
+
+// mojibakeScore 文字的合理程度，0到1
+// 常用汉字及ASCII得分最高，乱码中常见的拉丁字母、符号及控制字符得分较低。
+func mojibakeScore(s string) float64 {
+	total, score := 0, 0.0
+	for _, r := range s {
+		total++
+		switch {
+		case r < 0x80:
+			if unicode.IsPrint(r) || unicode.IsSpace(r) {
+				score++
+			}
+		case commonHans[r] || commonHantHans[r]:
+			score++
+		case strings.ContainsRune("“”‘’–—…", r):
+			score += 0.9
+		case unicode.Is(unicode.Han, r):
+			score += 0.7
+		case isCJKPunct(r):
+			score += 0.8
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana, unicode.Hangul):
+			score += 0.5
+		case r == utf8.RuneError, unicode.IsControl(r), r >= 0xe000 && r <= 0xf8ff:
+		case r < 0x100 && unicode.IsLetter(r):
+			score += 0.4
+		case r < 0x100, cp1252High[r] != 0:
+			score += 0.1
+		case unicode.IsLetter(r):
+			score += 0.5
+		default:
+			score += 0.2
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+
+	// 包含汉字时更可能是正常的中文内容 ...
+	if String(s).ExistHan() {
+		score += 0.1 * float64(total)
+	}
+	return score / float64(total)
+}
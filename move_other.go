@@ -0,0 +1,14 @@
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+package snake
+
+import (
+	"errors"
+	"syscall"
+)
+
+// isCrossDevice 判断重命名失败是否因为跨设备
+func isCrossDevice(err error) bool {
+	return errors.Is(err, syscall.EXDEV)
+}
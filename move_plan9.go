@@ -0,0 +1,6 @@
+package snake
+
+// isCrossDevice plan9 不区分跨设备错误，不做复制回退
+func isCrossDevice(err error) bool {
+	return false
+}
@@ -0,0 +1,14 @@
+package snake
+
+import (
+	"errors"
+	"syscall"
+)
+
+// errNotSameDevice Windows 的 ERROR_NOT_SAME_DEVICE
+const errNotSameDevice = syscall.Errno(17)
+
+// isCrossDevice 判断重命名失败是否因为跨设备
+func isCrossDevice(err error) bool {
+	return errors.Is(err, errNotSameDevice)
+}
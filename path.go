@@ -0,0 +1,138 @@
+package snake
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ---------------------------------------
+// 路径处理 :
+// 以下方法均不修改当前路径，而是返回新的FileSystem。
+
+// Rel 返回相对于base的路径
+// 例子：
+// snake.FS("/www/dede/uploads/a.jpg").Rel("/www/dede")
+// 返回：uploads/a.jpg
+func (sk *snakeFileSystem) Rel(base string) (FileSystem, error) {
+	p, err := filepath.Rel(FS(base).Get(), sk.Get())
+	if err != nil {
+		return nil, err
+	}
+	return sk.derive(p), nil
+}
+
+// Abs 返回绝对路径
+func (sk *snakeFileSystem) Abs() FileSystem {
+	if p, err := filepath.Abs(sk.Get()); err == nil {
+		return sk.derive(p)
+	}
+	return sk.derive(sk.Get())
+}
+
+// Stem 返回不含扩展名的文件名
+// 例子：
+// snake.FS("a/b/index.htm").Stem()
+// 返回：index
+func (sk *snakeFileSystem) Stem() string {
+	return strings.TrimSuffix(sk.Base(), sk.Ext())
+}
+
+// WithExt 替换扩展名，ext可带"."，为空时去除扩展名
+// 例子：
+// snake.FS("a/b/index.htm").WithExt("html")
+// 返回：a/b/index.html
+func (sk *snakeFileSystem) WithExt(ext string) FileSystem {
+	if ext != "" && !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	return sk.derive(strings.TrimSuffix(sk.Get(), sk.Ext()) + ext)
+}
+
+// WithBase 替换路径中最后一个元素
+// 例子：
+// snake.FS("a/b/index.htm").WithBase("list.htm")
+// 返回：a/b/list.htm
+func (sk *snakeFileSystem) WithBase(name string) FileSystem {
+	return sk.derive(sk.Dir(), name)
+}
+
+// Parent 返回第n级上层目录，n默认为1
+// 例子：
+// snake.FS("a/b/c/index.htm").Parent(2)
+// 返回：a/b
+func (sk *snakeFileSystem) Parent(n ...int) FileSystem {
+	level := 1
+	if len(n) > 0 {
+		level = n[0]
+	}
+
+	p := sk.Get()
+	for i := 0; i < level; i++ {
+		p = filepath.Dir(p)
+	}
+	return sk.derive(p)
+}
+
+// Parts 返回路径中的各个元素，绝对路径的第一个元素为根目录
+// 例子：
+// snake.FS("/www/dede/index.php").Parts()
+// 返回：[]string{"/", "www", "dede", "index.php"}
+func (sk *snakeFileSystem) Parts() []string {
+	p := sk.Get()
+	var res []string
+
+	if vol := filepath.VolumeName(p); vol != "" {
+		res = append(res, vol+string(filepath.Separator))
+		p = strings.TrimPrefix(p[len(vol):], string(filepath.Separator))
+	} else if filepath.IsAbs(p) {
+		res = append(res, string(filepath.Separator))
+		p = strings.TrimPrefix(p, string(filepath.Separator))
+	}
+
+	for _, v := range strings.Split(p, string(filepath.Separator)) {
+		if v != "" {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+// Match 判断路径是否符合规则，规则会同时与文件名及完整路径进行匹配
+// 例子：
+// snake.FS("templets/default/index.htm").Match("*.htm")
+// 返回：true
+func (sk *snakeFileSystem) Match(pattern string) bool {
+	return matchPatterns(sk.Get(), []string{pattern})
+}
+
+// IsWithin 判断路径是否位于base目录内（包含base本身）
+func (sk *snakeFileSystem) IsWithin(base string) bool {
+	b, err := filepath.Abs(FS(base).Get())
+	if err != nil {
+		return false
+	}
+	p, err := filepath.Abs(sk.Get())
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(b, p)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// Expand 展开路径中的"~"及环境变量
+// 例子：
+// snake.FS("~/sites/$SITE/data").Expand()
+// 返回：/home/dede/sites/dedecms/data
+func (sk *snakeFileSystem) Expand() FileSystem {
+	p := os.ExpandEnv(sk.Path)
+	if p == "~" || strings.HasPrefix(p, "~/") || strings.HasPrefix(p, `~\`) {
+		if home, err := os.UserHomeDir(); err == nil {
+			p = home + p[1:]
+		}
+	}
+	return sk.derive(p)
+}
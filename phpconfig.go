@@ -0,0 +1,669 @@
+package snake
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PHPExpr 无法解析为字面量的PHP表达式原文，例如：DEDEROOT.'/data'
+type PHPExpr string
+
+// PHPConfig PHP配置文件解析结果
+type PHPConfig struct {
+	Vars    map[string]interface{} // $变量，键名不含"$"
+	Defines map[string]interface{} // define()常量
+	Charset string                 // 文件编码，例如：UTF-8、GBK
+
+	src   string             // UTF-8 文件内容
+	spans map[string]phpSpan // 变量、常量值在src中的位置，常量键名以"define:"开头
+}
+
+// phpSpan 值在源码中的位置
+type phpSpan struct {
+	start, end int
+}
+
+// PHPConfig 解析PHP配置文件，例如 DedeCMS 的 data/common.inc.php
+// 可解析 $cfg_xxx = 'value'; 形式的赋值、define('X', 'value'); 常量及 array() / [] 数组字面量，
+// GBK等非UTF-8文件会根据Charset()检测结果自动转换。
+// 例子：
+// conf, err := snake.FS("data/common.inc.php").PHPConfig()
+// conf.Vars["cfg_dbhost"]
+func (sk *snakeFileSystem) PHPConfig() (*PHPConfig, error) {
+	data, err := ioutil.ReadFile(sk.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	src, charset, err := decodeText(data)
+	if err != nil {
+		return nil, err
+	}
+
+	conf := &PHPConfig{
+		Vars:    map[string]interface{}{},
+		Defines: map[string]interface{}{},
+		Charset: charset,
+		src:     src,
+		spans:   map[string]phpSpan{},
+	}
+	(&phpParser{src: src, conf: conf}).parse()
+	return conf, nil
+}
+
+// SetPHPConfig 修改PHP配置文件中的变量或常量值，并保持文件其余内容及原有编码不变
+// name为变量名（可带"$"）或define常量名，不存在时追加变量赋值语句。
+// 通过临时文件原子替换原文件，写入中途失败时原文件保持不变。
+// 例子：
+// snake.FS("data/common.inc.php").SetPHPConfig("cfg_dbhost", "127.0.0.1")
+func (sk *snakeFileSystem) SetPHPConfig(name string, value interface{}) error {
+	conf, err := sk.PHPConfig()
+	if err != nil {
+		return err
+	}
+
+	name = strings.TrimPrefix(name, "$")
+	src := conf.src
+
+	span, ok := conf.spans[name]
+	if !ok {
+		span, ok = conf.spans["define:"+name]
+	}
+
+	if ok {
+		literal, err := phpLiteral(value, strings.HasPrefix(src[span.start:span.end], `"`))
+		if err != nil {
+			return err
+		}
+		src = src[:span.start] + literal + src[span.end:]
+	} else {
+		literal, err := phpLiteral(value, false)
+		if err != nil {
+			return err
+		}
+		line := "$" + name + " = " + literal + ";\n"
+		if i := strings.LastIndex(src, "?>"); i >= 0 && strings.TrimSpace(src[i+2:]) == "" {
+			src = src[:i] + line + src[i:]
+		} else {
+			if !strings.HasSuffix(src, "\n") {
+				src += "\n"
+			}
+			src += line
+		}
+	}
+
+	data, err := encodeText(src, conf.Charset)
+	if err != nil {
+		return err
+	}
+
+	return sk.AtomicWriter(data)
+}
+
+// Get 获取变量或常量值，变量优先
+func (c *PHPConfig) Get(name string) (interface{}, bool) {
+	name = strings.TrimPrefix(name, "$")
+	if v, ok := c.Vars[name]; ok {
+		return v, true
+	}
+	v, ok := c.Defines[name]
+	return v, ok
+}
+
+// Decode 将配置解析到结构体
+// 字段通过 php 标签指定变量名，未指定时依次匹配：snake_case字段名、cfg_前缀的snake_case字段名、小写字段名。
+// 例子：
+// type Site struct { DBHost string `php:"cfg_dbhost"` }
+func (c *PHPConfig) Decode(out interface{}) error {
+	v := reflect.ValueOf(out)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return errors.New("out should be a pointer to struct")
+	}
+
+	v = v.Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		fieldStruct := t.Field(i)
+		if fieldStruct.PkgPath != "" {
+			continue
+		}
+
+		var names []string
+		if tag := fieldStruct.Tag.Get("php"); tag != "" {
+			if tag == "-" {
+				continue
+			}
+			names = []string{tag}
+		} else {
+			snake := String(fieldStruct.Name).SnakeCase().ToLower().Get()
+			lower := strings.ToLower(fieldStruct.Name)
+			names = []string{snake, "cfg_" + snake, lower, "cfg_" + lower}
+		}
+
+		for _, name := range names {
+			if value, ok := c.Get(name); ok {
+				if err := setPHPValue(v.Field(i), value); err != nil {
+					return fmt.Errorf("%v: %v", fieldStruct.Name, err)
+				}
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// phpParser PHP配置解析器，仅识别赋值语句与define常量
+type phpParser struct {
+	src  string
+	pos  int
+	conf *PHPConfig
+}
+
+// parse 逐条扫描语句
+func (p *phpParser) parse() {
+	for p.pos < len(p.src) {
+		p.skipSpace()
+		if p.pos >= len(p.src) {
+			return
+		}
+
+		switch c := p.src[p.pos]; {
+		case strings.HasPrefix(p.src[p.pos:], "<?php"):
+			p.pos += 5
+		case strings.HasPrefix(p.src[p.pos:], "<?"), strings.HasPrefix(p.src[p.pos:], "?>"):
+			p.pos += 2
+		case c == '$':
+			p.assignment()
+		case p.hasWord("define"):
+			p.define()
+		default:
+			p.skipStatement()
+		}
+	}
+}
+
+// assignment 解析 $name = value; 或 $name['key'] = value;
+func (p *phpParser) assignment() {
+	p.pos++
+	name := p.ident()
+	if name == "" {
+		p.skipStatement()
+		return
+	}
+
+	// 数组元素赋值 $name['a']['b'] = value; ...
+	var keys []string
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.src) || p.src[p.pos] != '[' {
+			break
+		}
+		p.pos++
+		p.skipSpace()
+		key, ok := p.value()
+		p.skipSpace()
+		if !ok || p.pos >= len(p.src) || p.src[p.pos] != ']' {
+			p.skipStatement()
+			return
+		}
+		p.pos++
+		keys = append(keys, fmt.Sprint(key))
+	}
+
+	p.skipSpace()
+	if !strings.HasPrefix(p.src[p.pos:], "=") || strings.HasPrefix(p.src[p.pos:], "==") || strings.HasPrefix(p.src[p.pos:], "=>") {
+		p.skipStatement()
+		return
+	}
+	p.pos++
+	p.skipSpace()
+
+	value, span := p.statementValue(';')
+	if len(keys) == 0 {
+		p.conf.Vars[name] = value
+		p.conf.spans[name] = span
+		return
+	}
+
+	m, ok := p.conf.Vars[name].(map[string]interface{})
+	if !ok {
+		m = map[string]interface{}{}
+		p.conf.Vars[name] = m
+	}
+	for _, k := range keys[:len(keys)-1] {
+		next, ok := m[k].(map[string]interface{})
+		if !ok {
+			next = map[string]interface{}{}
+			m[k] = next
+		}
+		m = next
+	}
+	m[keys[len(keys)-1]] = value
+}
+
+// define 解析 define('NAME', value);
+func (p *phpParser) define() {
+	p.pos += len("define")
+	p.skipSpace()
+	if p.pos >= len(p.src) || p.src[p.pos] != '(' {
+		p.skipStatement()
+		return
+	}
+	p.pos++
+	p.skipSpace()
+
+	name, ok := p.value()
+	p.skipSpace()
+	if !ok || p.pos >= len(p.src) || p.src[p.pos] != ',' {
+		p.skipStatement()
+		return
+	}
+	p.pos++
+	p.skipSpace()
+
+	value, span := p.statementValue(')')
+	key := fmt.Sprint(name)
+	p.conf.Defines[key] = value
+	p.conf.spans["define:"+key] = span
+	if p.pos < len(p.src) && p.src[p.pos] == ')' {
+		p.pos++
+	}
+	p.skipStatement()
+}
+
+// statementValue 解析语句中的值，值之后不是结束符时作为表达式原文返回
+func (p *phpParser) statementValue(end byte) (interface{}, phpSpan) {
+	start := p.pos
+	value, ok := p.value()
+	stop := p.pos
+	p.skipSpace()
+	if ok && p.pos < len(p.src) && p.src[p.pos] == end {
+		if end == ';' {
+			p.pos++
+		}
+		return value, phpSpan{start, stop}
+	}
+
+	// 表达式：扫描到结束符 ...
+	p.pos = start
+	p.scanUntil(end)
+	expr := strings.TrimSpace(p.src[start:p.pos])
+	if end == ';' && p.pos < len(p.src) {
+		p.pos++
+	}
+	return PHPExpr(expr), phpSpan{start, start + len(expr)}
+}
+
+// value 解析字面量
+func (p *phpParser) value() (interface{}, bool) {
+	if p.pos >= len(p.src) {
+		return nil, false
+	}
+
+	switch c := p.src[p.pos]; {
+	case c == '\'':
+		return p.singleQuoted()
+	case c == '"':
+		return p.doubleQuoted()
+	case c == '[':
+		p.pos++
+		return p.array(']')
+	case p.hasWord("array"):
+		save := p.pos
+		p.pos += len("array")
+		p.skipSpace()
+		if p.pos < len(p.src) && p.src[p.pos] == '(' {
+			p.pos++
+			return p.array(')')
+		}
+		p.pos = save
+		return nil, false
+	case c == '-' || c == '+' || c == '.' || (c >= '0' && c <= '9'):
+		return p.number()
+	}
+
+	word := strings.ToLower(p.ident())
+	switch word {
+	case "true":
+		return true, true
+	case "false":
+		return false, true
+	case "null":
+		return nil, true
+	}
+	return nil, false
+}
+
+// array 解析数组字面量，键为连续整数时返回[]interface{}，否则返回map[string]interface{}
+func (p *phpParser) array(end byte) (interface{}, bool) {
+	var keys []string
+	values := map[string]interface{}{}
+	next := 0
+	list := true
+
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.src) {
+			return nil, false
+		}
+		if p.src[p.pos] == end {
+			p.pos++
+			break
+		}
+
+		v, ok := p.value()
+		if !ok {
+			return nil, false
+		}
+		p.skipSpace()
+
+		key := strconv.Itoa(next)
+		if strings.HasPrefix(p.src[p.pos:], "=>") {
+			p.pos += 2
+			p.skipSpace()
+			key = fmt.Sprint(v)
+			if v, ok = p.value(); !ok {
+				return nil, false
+			}
+			p.skipSpace()
+		}
+
+		if n, err := strconv.Atoi(key); err == nil && n == next {
+			next++
+		} else {
+			list = false
+			if err == nil && n > next {
+				next = n + 1
+			}
+		}
+		if _, exist := values[key]; !exist {
+			keys = append(keys, key)
+		}
+		values[key] = v
+
+		if p.pos < len(p.src) && p.src[p.pos] == ',' {
+			p.pos++
+		}
+	}
+
+	if list {
+		res := make([]interface{}, len(keys))
+		for i, k := range keys {
+			res[i] = values[k]
+		}
+		return res, true
+	}
+	return values, true
+}
+
+// singleQuoted 解析单引号字符串
+func (p *phpParser) singleQuoted() (interface{}, bool) {
+	var b strings.Builder
+	for p.pos++; p.pos < len(p.src); p.pos++ {
+		c := p.src[p.pos]
+		if c == '\\' && p.pos+1 < len(p.src) && (p.src[p.pos+1] == '\'' || p.src[p.pos+1] == '\\') {
+			p.pos++
+			b.WriteByte(p.src[p.pos])
+			continue
+		}
+		if c == '\'' {
+			p.pos++
+			return b.String(), true
+		}
+		b.WriteByte(c)
+	}
+	return nil, false
+}
+
+// doubleQuoted 解析双引号字符串，变量不做替换
+func (p *phpParser) doubleQuoted() (interface{}, bool) {
+	var b strings.Builder
+	for p.pos++; p.pos < len(p.src); p.pos++ {
+		c := p.src[p.pos]
+		if c == '\\' && p.pos+1 < len(p.src) {
+			p.pos++
+			switch e := p.src[p.pos]; e {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'r':
+				b.WriteByte('\r')
+			case '\\', '"', '$':
+				b.WriteByte(e)
+			default:
+				b.WriteByte('\\')
+				b.WriteByte(e)
+			}
+			continue
+		}
+		if c == '"' {
+			p.pos++
+			return b.String(), true
+		}
+		b.WriteByte(c)
+	}
+	return nil, false
+}
+
+// number 解析整数或浮点数
+func (p *phpParser) number() (interface{}, bool) {
+	start := p.pos
+	for p.pos < len(p.src) && strings.IndexByte("+-.0123456789eExXabcdefABCDEF_", p.src[p.pos]) >= 0 {
+		p.pos++
+	}
+	text := strings.Replace(p.src[start:p.pos], "_", "", -1)
+	if n, err := strconv.ParseInt(text, 0, 64); err == nil {
+		return n, true
+	}
+	if f, err := strconv.ParseFloat(text, 64); err == nil {
+		return f, true
+	}
+	p.pos = start
+	return nil, false
+}
+
+// ident 读取标识符
+func (p *phpParser) ident() string {
+	start := p.pos
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		if c == '_' || c >= 0x80 || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (p.pos > start && c >= '0' && c <= '9') {
+			p.pos++
+			continue
+		}
+		break
+	}
+	return p.src[start:p.pos]
+}
+
+// hasWord 判断当前位置是否为指定关键字（不区分大小写）
+func (p *phpParser) hasWord(word string) bool {
+	end := p.pos + len(word)
+	if end > len(p.src) || !strings.EqualFold(p.src[p.pos:end], word) {
+		return false
+	}
+	if end < len(p.src) {
+		c := p.src[end]
+		if c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// skipSpace 跳过空白及注释
+func (p *phpParser) skipSpace() {
+	for p.pos < len(p.src) {
+		switch {
+		case strings.IndexByte(" \t\r\n", p.src[p.pos]) >= 0:
+			p.pos++
+		case strings.HasPrefix(p.src[p.pos:], "//"), p.src[p.pos] == '#':
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' && !strings.HasPrefix(p.src[p.pos:], "?>") {
+				p.pos++
+			}
+		case strings.HasPrefix(p.src[p.pos:], "/*"):
+			if i := strings.Index(p.src[p.pos+2:], "*/"); i >= 0 {
+				p.pos += i + 4
+			} else {
+				p.pos = len(p.src)
+			}
+		default:
+			return
+		}
+	}
+}
+
+// skipStatement 跳过当前语句
+func (p *phpParser) skipStatement() {
+	start := p.pos
+	p.scanUntil(';')
+	if p.pos < len(p.src) {
+		p.pos++
+	}
+	if p.pos == start {
+		p.pos++
+	}
+}
+
+// scanUntil 扫描到同层级的结束符，忽略字符串及括号内的内容
+func (p *phpParser) scanUntil(end byte) {
+	depth := 0
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch {
+		case c == '\'':
+			p.singleQuoted()
+			continue
+		case c == '"':
+			p.doubleQuoted()
+			continue
+		case depth == 0 && c == end:
+			return
+		case depth == 0 && end == ';' && (c == '{' || c == '}'):
+			return
+		case strings.HasPrefix(p.src[p.pos:], "?>"):
+			return
+		case c == '(' || c == '[' || c == '{':
+			depth++
+		case (c == ')' || c == ']' || c == '}') && depth > 0:
+			depth--
+		}
+		p.pos++
+	}
+}
+
+// phpLiteral 将Go值转换为PHP字面量
+func phpLiteral(value interface{}, double bool) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "null", nil
+	case PHPExpr:
+		return string(v), nil
+	case string:
+		if double {
+			return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`).Replace(v) + `"`, nil
+		}
+		return `'` + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(v) + `'`, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return fmt.Sprint(value), nil
+	case reflect.String:
+		return phpLiteral(rv.String(), double)
+	case reflect.Slice, reflect.Array:
+		var items []string
+		for i := 0; i < rv.Len(); i++ {
+			item, err := phpLiteral(rv.Index(i).Interface(), double)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, item)
+		}
+		return "array(" + strings.Join(items, ", ") + ")", nil
+	case reflect.Map:
+		keys := rv.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+		})
+		var items []string
+		for _, k := range keys {
+			key, err := phpLiteral(fmt.Sprint(k.Interface()), double)
+			if err != nil {
+				return "", err
+			}
+			item, err := phpLiteral(rv.MapIndex(k).Interface(), double)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, key+" => "+item)
+		}
+		return "array(" + strings.Join(items, ", ") + ")", nil
+	}
+	return "", fmt.Errorf("unsupported php value type: %T", value)
+}
+
+// setPHPValue 将解析出的PHP值写入结构体字段
+func setPHPValue(field reflect.Value, value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	if expr, ok := value.(PHPExpr); ok {
+		value = string(expr)
+	}
+
+	text := fmt.Sprint(value)
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(text)
+		return nil
+	case reflect.Bool:
+		switch strings.ToLower(text) {
+		case "", "0", "false", "n", "no", "off":
+			field.SetBool(false)
+		default:
+			field.SetBool(true)
+		}
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(strings.TrimSpace(text), 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(strings.TrimSpace(text), 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+		return nil
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(strings.TrimSpace(text), 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+		return nil
+	}
+
+	// 数组、map、结构体通过JSON转换 ...
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, field.Addr().Interface())
+}
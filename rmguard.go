@@ -0,0 +1,176 @@
+package snake
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// ErrRmProtected 删除受保护的路径
+var ErrRmProtected = errors.New("refusing to remove protected path")
+
+// ErrRmOutsideBase 删除Base目录之外的路径
+var ErrRmOutsideBase = errors.New("refusing to remove path outside base")
+
+// ErrRmCanceled 删除被确认回调取消
+var ErrRmCanceled = errors.New("remove canceled")
+
+// defaultProtectedPaths 默认受保护的系统目录
+var defaultProtectedPaths = []string{
+	"/", "/bin", "/boot", "/dev", "/etc", "/home", "/lib", "/lib64", "/opt", "/proc",
+	"/root", "/sbin", "/sys", "/tmp", "/usr", "/var", "/Users", "/Applications", "/System", "/Library",
+}
+
+// rmGuard 删除保护设置
+var rmGuard = struct {
+	sync.RWMutex
+	protected []string
+	base      string
+}{protected: defaultProtectedPaths}
+
+// RmOptions 删除参数
+type RmOptions struct {
+	DryRun   bool               // 只返回将被删除的内容，不执行删除
+	Base     string             // 只允许删除Base目录内的路径，为空时使用SetRmBase的设置
+	MaxSize  int64              // 删除总大小超过该值时调用Confirm，0为不限制
+	MaxFiles int                // 删除文件数量超过该值时调用Confirm，0为不限制
+	Confirm  func(*RmPlan) bool // 超过阈值时的确认回调，返回false取消删除；未设置时超过阈值直接取消
+}
+
+// RmPlan 删除计划
+type RmPlan struct {
+	Path  string   // 删除的路径
+	Files []string // 将被删除的目录及文件，按路径排序
+	Size  int64    // 文件大小合计
+	Count int      // 文件数量（不含目录）
+}
+
+// ProtectPaths 添加受保护的路径，受保护的路径本身不能被Rm删除
+func ProtectPaths(paths ...string) {
+	rmGuard.Lock()
+	defer rmGuard.Unlock()
+	for _, p := range paths {
+		if abs, err := filepath.Abs(p); err == nil {
+			rmGuard.protected = append(rmGuard.protected, abs)
+		}
+	}
+}
+
+// ProtectedPaths 返回受保护的路径列表
+func ProtectedPaths() []string {
+	rmGuard.RLock()
+	defer rmGuard.RUnlock()
+	return append([]string{}, rmGuard.protected...)
+}
+
+// SetRmBase 设置Rm只允许删除base目录内的路径，为空时不限制
+func SetRmBase(base string) {
+	rmGuard.Lock()
+	defer rmGuard.Unlock()
+	rmGuard.base = base
+}
+
+// rmLinkPolicy Rm 删除时对符号链接的处理方式，与 os.RemoveAll 一致只删除链接本身，
+// 统计删除内容时必须使用相同的方式，否则统计结果与实际删除的内容不一致。
+const rmLinkPolicy = LinkNoFollow
+
+// RmWith 带保护的删除，支持试运行及超过阈值时确认
+// 例子：
+// plan, err := snake.FS("uploads/tmp").RmWith(snake.RmOptions{DryRun: true})
+// plan, err := snake.FS("uploads").RmWith(snake.RmOptions{Base: "/www/dede", MaxFiles: 1000, Confirm: ask})
+func (sk *snakeFileSystem) RmWith(opts ...RmOptions) (*RmPlan, error) {
+	opt := RmOptions{}
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+
+	if err := rmCheck(sk.Path, opt.Base); err != nil {
+		return nil, err
+	}
+
+	plan := &RmPlan{Path: sk.Get()}
+	err := walkTree(sk.Get(), rmLinkPolicy, func(p string, info os.FileInfo) error {
+		plan.Files = append(plan.Files, p)
+		if !info.IsDir() {
+			plan.Size += info.Size()
+			plan.Count++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(plan.Files)
+
+	if opt.DryRun {
+		return plan, nil
+	}
+
+	if (opt.MaxSize > 0 && plan.Size > opt.MaxSize) || (opt.MaxFiles > 0 && plan.Count > opt.MaxFiles) {
+		if opt.Confirm == nil || !opt.Confirm(plan) {
+			return plan, ErrRmCanceled
+		}
+	}
+
+	if !sk.Rm() {
+		return plan, &os.PathError{Op: "remove", Path: sk.Get(), Err: os.ErrInvalid}
+	}
+	return plan, nil
+}
+
+// rmCheck 检查路径是否允许删除
+// 空路径、根目录、受保护路径、当前工作目录与用户目录及其上级目录均不允许删除。
+func rmCheck(p, base string) error {
+	if p == "" {
+		return &os.PathError{Op: "remove", Path: p, Err: ErrRmProtected}
+	}
+
+	abs, err := filepath.Abs(p)
+	if err != nil {
+		return err
+	}
+
+	rmGuard.RLock()
+	protected := rmGuard.protected
+	if base == "" {
+		base = rmGuard.base
+	}
+	rmGuard.RUnlock()
+
+	if abs == filepath.Dir(abs) {
+		return &os.PathError{Op: "remove", Path: p, Err: ErrRmProtected}
+	}
+
+	for _, v := range protected {
+		if abs == filepath.Clean(v) {
+			return &os.PathError{Op: "remove", Path: p, Err: ErrRmProtected}
+		}
+	}
+
+	// 当前工作目录、用户目录及其上级目录 ...
+	var keep []string
+	if wd, err := os.Getwd(); err == nil {
+		keep = append(keep, wd)
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		keep = append(keep, home)
+	}
+	for _, v := range keep {
+		if pathWithin(abs, v) {
+			return &os.PathError{Op: "remove", Path: p, Err: ErrRmProtected}
+		}
+	}
+
+	if base != "" {
+		b, err := filepath.Abs(base)
+		if err != nil {
+			return err
+		}
+		if abs == b || !pathWithin(b, abs) {
+			return &os.PathError{Op: "remove", Path: p, Err: ErrRmOutsideBase}
+		}
+	}
+	return nil
+}
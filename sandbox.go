@@ -0,0 +1,631 @@
+package snake
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrSandboxEscape 路径超出沙箱根目录
+var ErrSandboxEscape = errors.New("path escapes sandbox")
+
+// maxSymlinkDepth 解析符号链接的最大次数
+const maxSymlinkDepth = 255
+
+// SandboxFS 限定在根目录内的FileSystem工厂
+type SandboxFS struct {
+	root string // 根目录绝对路径
+	real string // 根目录解析符号链接后的路径
+}
+
+// Sandbox 创建限定在root目录内的FileSystem工厂
+// 通过工厂创建的FileSystem，其路径均相对于root：拒绝绝对路径、越界的".."以及指向root之外的符号链接，
+// Add、Cp、Mv、Unzip、Find 等全部操作都会进行校验，越界时返回false或ErrSandboxEscape。
+// 沙箱内不跟随符号链接，Links(LinkFollow) 无效。
+// 例子：
+// sb, err := snake.Sandbox("/www/dede/uploads")
+// f, err := sb.FS(userPath)
+func Sandbox(root string) (*SandboxFS, error) {
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	real, err := filepath.EvalSymlinks(abs)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := os.Stat(real); err != nil {
+		return nil, err
+	} else if !info.IsDir() {
+		return nil, &os.PathError{Op: "sandbox", Path: root, Err: errors.New("not a directory")}
+	}
+	return &SandboxFS{root: abs, real: real}, nil
+}
+
+// Root 返回沙箱根目录
+func (s *SandboxFS) Root() string {
+	return s.root
+}
+
+// FS 根据相对于根目录的路径创建FileSystem
+func (s *SandboxFS) FS(str ...string) (FileSystem, error) {
+	p, err := s.join(s.root, str...)
+	if err == nil {
+		err = s.check(p, true)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.wrap(FS(p), nil), nil
+}
+
+// join 将相对路径拼接到base，拒绝绝对路径及超出根目录的路径
+func (s *SandboxFS) join(base string, str ...string) (string, error) {
+	p := base
+	for _, v := range str {
+		v = filepath.FromSlash(String(v).Replace(`\`, "/", true).Get())
+		if filepath.IsAbs(v) || filepath.VolumeName(v) != "" || strings.HasPrefix(v, "/") {
+			return p, &os.PathError{Op: "sandbox", Path: v, Err: ErrSandboxEscape}
+		}
+		p = filepath.Join(p, v)
+	}
+	if !pathWithin(s.root, p) {
+		return p, &os.PathError{Op: "sandbox", Path: p, Err: ErrSandboxEscape}
+	}
+	return p, nil
+}
+
+// check 校验路径解析符号链接后是否仍在根目录内，follow为false时不解析路径最后一个元素
+func (s *SandboxFS) check(p string, follow bool) error {
+	if !pathWithin(s.root, p) {
+		return &os.PathError{Op: "sandbox", Path: p, Err: ErrSandboxEscape}
+	}
+
+	real := p
+	if follow {
+		real = evalExisting(p, 0)
+	} else if p != s.root {
+		real = filepath.Join(evalExisting(filepath.Dir(p), 0), filepath.Base(p))
+	} else {
+		real = s.real
+	}
+
+	if !pathWithin(s.real, real) {
+		return &os.PathError{Op: "sandbox", Path: p, Err: ErrSandboxEscape}
+	}
+	return nil
+}
+
+// wrap 创建沙箱内的FileSystem，err不为nil时该FileSystem的全部操作均失败
+func (s *SandboxFS) wrap(fs FileSystem, err error) *sandboxFileSystem {
+	if fs.LinkPolicy() != LinkNoFollow {
+		fs = fs.Links(LinkNoFollow)
+	}
+	if err == nil && !pathWithin(s.root, fs.Get()) {
+		err = &os.PathError{Op: "sandbox", Path: fs.Get(), Err: ErrSandboxEscape}
+	}
+	return &sandboxFileSystem{FileSystem: fs, box: s, err: err}
+}
+
+// pathWithin 判断路径是否位于base目录内（包含base本身）
+func pathWithin(base, p string) bool {
+	rel, err := filepath.Rel(base, p)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
+
+// evalExisting 解析路径中已存在部分的符号链接，不存在的部分原样拼接，
+// 指向不存在目标的符号链接会按链接内容继续解析。
+func evalExisting(p string, depth int) string {
+	var rest []string
+	cur := p
+	for {
+		if real, err := filepath.EvalSymlinks(cur); err == nil {
+			return filepath.Join(append([]string{real}, rest...)...)
+		}
+
+		// 指向不存在目标的符号链接 ...
+		if info, err := os.Lstat(cur); err == nil && info.Mode()&os.ModeSymlink != 0 && depth < maxSymlinkDepth {
+			if target, err := os.Readlink(cur); err == nil {
+				if !filepath.IsAbs(target) {
+					target = filepath.Join(filepath.Dir(cur), target)
+				}
+				return filepath.Join(append([]string{evalExisting(target, depth+1)}, rest...)...)
+			}
+		}
+
+		parent := filepath.Dir(cur)
+		if parent == cur {
+			return p
+		}
+		rest = append([]string{filepath.Base(cur)}, rest...)
+		cur = parent
+	}
+}
+
+// sandboxFileSystem 沙箱内的FileSystem，所有涉及磁盘的操作先校验路径
+type sandboxFileSystem struct {
+	FileSystem
+	box *SandboxFS
+	err error
+}
+
+// inner 返回内部的snakeFileSystem
+func (sb *sandboxFileSystem) inner() *snakeFileSystem {
+	return sb.FileSystem.(*snakeFileSystem)
+}
+
+// derived 根据内部FileSystem返回的新路径创建沙箱FileSystem
+func (sb *sandboxFileSystem) derived(fs FileSystem) FileSystem {
+	return sb.box.wrap(fs, sb.err)
+}
+
+// target 返回操作的目标路径并校验，dst为相对于沙箱根目录的路径
+func (sb *sandboxFileSystem) target(follow bool, dst ...string) (string, error) {
+	if sb.err != nil {
+		return "", sb.err
+	}
+	p := sb.Get()
+	if len(dst) > 0 {
+		var err error
+		if p, err = sb.box.join(sb.box.root, dst[0]); err != nil {
+			return "", err
+		}
+	}
+	return p, sb.box.check(p, follow)
+}
+
+// ok 校验当前路径
+func (sb *sandboxFileSystem) ok(follow bool) error {
+	_, err := sb.target(follow)
+	return err
+}
+
+// ---------------------------------------
+// 路径 :
+
+func (sb *sandboxFileSystem) Add(str ...string) FileSystem {
+	p, err := sb.box.join(sb.Get(), str...)
+	if sb.err != nil {
+		err = sb.err
+	}
+	return sb.box.wrap(sb.inner().derive(p), err)
+}
+
+func (sb *sandboxFileSystem) ReplaceRoot(str ...string) FileSystem {
+	return sb.derived(sb.FileSystem.ReplaceRoot(str...))
+}
+
+func (sb *sandboxFileSystem) Rel(base string) (FileSystem, error) {
+	b, err := sb.box.join(sb.box.root, base)
+	if err != nil {
+		return nil, err
+	}
+	rel, err := filepath.Rel(b, sb.Get())
+	if err != nil {
+		return nil, err
+	}
+	return sb.box.FS(rel)
+}
+
+func (sb *sandboxFileSystem) Abs() FileSystem {
+	return sb.derived(sb.FileSystem.Abs())
+}
+
+func (sb *sandboxFileSystem) WithExt(ext string) FileSystem {
+	return sb.derived(sb.FileSystem.WithExt(ext))
+}
+
+func (sb *sandboxFileSystem) WithBase(name string) FileSystem {
+	if strings.ContainsAny(name, `/\`) {
+		return sb.box.wrap(sb.FileSystem, &os.PathError{Op: "sandbox", Path: name, Err: ErrSandboxEscape})
+	}
+	return sb.derived(sb.FileSystem.WithBase(name))
+}
+
+func (sb *sandboxFileSystem) Parent(n ...int) FileSystem {
+	return sb.derived(sb.FileSystem.Parent(n...))
+}
+
+func (sb *sandboxFileSystem) Expand() FileSystem {
+	return sb.derived(sb.FileSystem.Expand())
+}
+
+// Links 沙箱内不跟随符号链接
+func (sb *sandboxFileSystem) Links(policy LinkPolicy) FileSystem {
+	return sb.derived(sb.FileSystem)
+}
+
+func (sb *sandboxFileSystem) AutoLock(on ...bool) FileSystem {
+	return sb.derived(sb.FileSystem.AutoLock(on...))
+}
+
+// ---------------------------------------
+// 判断 :
+
+func (sb *sandboxFileSystem) IsDir(dst ...string) bool {
+	p, err := sb.target(true, dst...)
+	return err == nil && sb.FileSystem.IsDir(p)
+}
+
+func (sb *sandboxFileSystem) IsFile(dst ...string) bool {
+	p, err := sb.target(true, dst...)
+	return err == nil && sb.FileSystem.IsFile(p)
+}
+
+func (sb *sandboxFileSystem) Exist(dst ...string) bool {
+	p, err := sb.target(true, dst...)
+	return err == nil && sb.FileSystem.Exist(p)
+}
+
+func (sb *sandboxFileSystem) IsSymlink(dst ...string) bool {
+	p, err := sb.target(false, dst...)
+	return err == nil && sb.FileSystem.IsSymlink(p)
+}
+
+func (sb *sandboxFileSystem) Lexist(dst ...string) bool {
+	p, err := sb.target(false, dst...)
+	return err == nil && sb.FileSystem.Lexist(p)
+}
+
+// ---------------------------------------
+// 目录 :
+
+func (sb *sandboxFileSystem) Ls(opt ...string) []string {
+	if sb.ok(true) != nil {
+		return nil
+	}
+	return sb.filter(sb.FileSystem.Ls(opt...))
+}
+
+func (sb *sandboxFileSystem) Find(opt ...string) []string {
+	if sb.ok(true) != nil {
+		return nil
+	}
+	return sb.filter(sb.FileSystem.Find(opt...))
+}
+
+// filter 过滤超出沙箱的路径
+func (sb *sandboxFileSystem) filter(list []string) []string {
+	var res []string
+	for _, v := range list {
+		if sb.box.check(v, false) == nil {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+func (sb *sandboxFileSystem) MkDir(dst ...string) bool {
+	p, err := sb.target(true, dst...)
+	return err == nil && sb.FileSystem.MkDir(p)
+}
+
+func (sb *sandboxFileSystem) TreeHash(opts ...TreeHashOptions) (*TreeHashResult, error) {
+	if err := sb.ok(true); err != nil {
+		return nil, err
+	}
+	return sb.FileSystem.TreeHash(opts...)
+}
+
+func (sb *sandboxFileSystem) Du() (*DiskUsage, error) {
+	if err := sb.ok(true); err != nil {
+		return nil, err
+	}
+	return sb.FileSystem.Du()
+}
+
+func (sb *sandboxFileSystem) Tree(opts ...TreeOptions) *SnakeString {
+	if sb.ok(true) != nil {
+		return String()
+	}
+	return sb.FileSystem.Tree(opts...)
+}
+
+// ---------------------------------------
+// 文件 :
+
+func (sb *sandboxFileSystem) MkFile(dst ...string) (FileOperate, bool) {
+	p, err := sb.target(true, dst...)
+	if err != nil {
+		return fileError(err), false
+	}
+	return sb.FileSystem.MkFile(p)
+}
+
+func (sb *sandboxFileSystem) Write(src string, add ...bool) bool {
+	return sb.ok(true) == nil && sb.FileSystem.Write(src, add...)
+}
+
+func (sb *sandboxFileSystem) ByteWriter(src []byte, add ...bool) (bool, error) {
+	if err := sb.ok(true); err != nil {
+		return false, err
+	}
+	return sb.FileSystem.ByteWriter(src, add...)
+}
+
+func (sb *sandboxFileSystem) AtomicWriter(src []byte) error {
+	if err := sb.ok(true); err != nil {
+		return err
+	}
+	return sb.FileSystem.AtomicWriter(src)
+}
+
+func (sb *sandboxFileSystem) Open(add ...bool) (FileOperate, bool) {
+	if err := sb.ok(true); err != nil {
+		return fileError(err), false
+	}
+	return sb.FileSystem.Open(add...)
+}
+
+func (sb *sandboxFileSystem) OpenFile(opt ...OpenOptions) (FileOperate, error) {
+	if err := sb.ok(true); err != nil {
+		return fileError(err), err
+	}
+	return sb.FileSystem.OpenFile(opt...)
+}
+
+func (sb *sandboxFileSystem) Rm(dst ...string) bool {
+	p, err := sb.target(false, dst...)
+	if err != nil || p == sb.box.root {
+		return false
+	}
+	return sb.FileSystem.Rm(p)
+}
+
+func (sb *sandboxFileSystem) RmWith(opts ...RmOptions) (*RmPlan, error) {
+	if err := sb.ok(false); err != nil {
+		return nil, err
+	}
+	if sb.Get() == sb.box.root {
+		return nil, &os.PathError{Op: "remove", Path: sb.Get(), Err: os.ErrPermission}
+	}
+	return sb.FileSystem.RmWith(opts...)
+}
+
+func (sb *sandboxFileSystem) Rn(newname string) bool {
+	if strings.ContainsAny(newname, `/\`) || sb.ok(false) != nil || sb.Get() == sb.box.root {
+		return false
+	}
+	if sb.box.check(filepath.Join(sb.Dir(), newname), false) != nil {
+		return false
+	}
+	return sb.FileSystem.Rn(newname)
+}
+
+// Mv newpath为相对于沙箱根目录的路径
+func (sb *sandboxFileSystem) Mv(newpath string) bool {
+	dir, err := sb.box.join(sb.box.root, newpath)
+	if err != nil || sb.ok(false) != nil || sb.Get() == sb.box.root || sb.box.check(dir, true) != nil {
+		return false
+	}
+	return sb.FileSystem.Mv(dir)
+}
+
+// Cp dir为相对于沙箱根目录的路径
+func (sb *sandboxFileSystem) Cp(dir string, overwrite bool) bool {
+	d, err := sb.box.join(sb.box.root, dir)
+	if err != nil || sb.ok(true) != nil || sb.box.check(d, true) != nil {
+		return false
+	}
+	return sb.FileSystem.Cp(d, overwrite)
+}
+
+func (sb *sandboxFileSystem) Unzip() (string, error) {
+	if err := sb.ok(true); err != nil {
+		return "", err
+	}
+	return unzip(sb.Get(), func(p string) error {
+		return sb.box.check(p, true)
+	})
+}
+
+func (sb *sandboxFileSystem) Trash() (string, error) {
+	if err := sb.ok(false); err != nil {
+		return "", err
+	}
+	if sb.Get() == sb.box.root {
+		return "", &os.PathError{Op: "trash", Path: sb.Get(), Err: os.ErrPermission}
+	}
+	return sb.FileSystem.Trash()
+}
+
+// ---------------------------------------
+// 内容 :
+
+func (sb *sandboxFileSystem) ContentType() string {
+	if sb.ok(true) != nil {
+		return ""
+	}
+	return sb.FileSystem.ContentType()
+}
+
+func (sb *sandboxFileSystem) MimeDetect() MimeInfo {
+	if sb.ok(true) != nil {
+		return MimeInfo{Extension: sb.MimeTypes()}
+	}
+	return sb.FileSystem.MimeDetect()
+}
+
+func (sb *sandboxFileSystem) MD5() string {
+	if sb.ok(true) != nil {
+		return ""
+	}
+	return sb.FileSystem.MD5()
+}
+
+func (sb *sandboxFileSystem) SHA256() string {
+	if sb.ok(true) != nil {
+		return ""
+	}
+	return sb.FileSystem.SHA256()
+}
+
+func (sb *sandboxFileSystem) Config(conf interface{}) error {
+	if err := sb.ok(true); err != nil {
+		return err
+	}
+	return sb.FileSystem.Config(conf)
+}
+
+// LoadConfig opts.Defaults为相对于沙箱根目录的路径，调用方的opts不会被修改
+func (sb *sandboxFileSystem) LoadConfig(conf interface{}, opts ...ConfigOptions) (ConfigReport, error) {
+	if err := sb.ok(true); err != nil {
+		return nil, err
+	}
+	if len(opts) > 0 && opts[0].Defaults != "" {
+		d, err := sb.target(true, opts[0].Defaults)
+		if err != nil {
+			return nil, err
+		}
+		opt := opts[0]
+		opt.Defaults = d
+		opts = []ConfigOptions{opt}
+	}
+	return sb.FileSystem.LoadConfig(conf, opts...)
+}
+
+func (sb *sandboxFileSystem) SaveConfig(conf interface{}) error {
+	if err := sb.ok(true); err != nil {
+		return err
+	}
+	return sb.FileSystem.SaveConfig(conf)
+}
+
+func (sb *sandboxFileSystem) WatchConfig(conf interface{}, onChange func(conf interface{}, err error), interval ...time.Duration) (*ConfigWatcher, error) {
+	if err := sb.ok(true); err != nil {
+		return nil, err
+	}
+	return sb.FileSystem.WatchConfig(conf, onChange, interval...)
+}
+
+func (sb *sandboxFileSystem) PHPConfig() (*PHPConfig, error) {
+	if err := sb.ok(true); err != nil {
+		return nil, err
+	}
+	return sb.FileSystem.PHPConfig()
+}
+
+func (sb *sandboxFileSystem) SetPHPConfig(name string, value interface{}) error {
+	if err := sb.ok(true); err != nil {
+		return err
+	}
+	return sb.FileSystem.SetPHPConfig(name, value)
+}
+
+func (sb *sandboxFileSystem) ReadText(opts ...TextOptions) (*SnakeString, string, error) {
+	if err := sb.ok(true); err != nil {
+		return nil, "", err
+	}
+	return sb.FileSystem.ReadText(opts...)
+}
+
+func (sb *sandboxFileSystem) WriteText(s string, charset string) error {
+	if err := sb.ok(true); err != nil {
+		return err
+	}
+	return sb.FileSystem.WriteText(s, charset)
+}
+
+// Transcode opts中的BackupDir为相对于沙箱根目录的路径，调用方的opts不会被修改
+func (sb *sandboxFileSystem) Transcode(from, to string, opts ...TranscodeOptions) ([]TranscodeResult, error) {
+	if err := sb.ok(true); err != nil {
+		return nil, err
+	}
+	if len(opts) > 0 && opts[0].BackupDir != "" {
+		dir, err := sb.box.join(sb.box.root, opts[0].BackupDir)
+		if err == nil {
+			err = sb.box.check(dir, true)
+		}
+		if err != nil {
+			return nil, err
+		}
+		opt := opts[0]
+		opt.BackupDir = dir
+		opts = []TranscodeOptions{opt}
+	}
+	return sb.FileSystem.Transcode(from, to, opts...)
+}
+
+// ---------------------------------------
+// 链接 :
+
+func (sb *sandboxFileSystem) Lstat() (os.FileInfo, error) {
+	if err := sb.ok(false); err != nil {
+		return nil, err
+	}
+	return sb.FileSystem.Lstat()
+}
+
+func (sb *sandboxFileSystem) Readlink() (string, error) {
+	if err := sb.ok(false); err != nil {
+		return "", err
+	}
+	return sb.FileSystem.Readlink()
+}
+
+// Symlink target为相对于链接所在目录的路径，且必须位于沙箱内
+func (sb *sandboxFileSystem) Symlink(target string) bool {
+	if filepath.IsAbs(target) || sb.ok(false) != nil {
+		return false
+	}
+	if sb.box.check(filepath.Join(sb.Dir(), target), true) != nil {
+		return false
+	}
+	return sb.FileSystem.Symlink(target)
+}
+
+// Hardlink target为相对于沙箱根目录的路径
+func (sb *sandboxFileSystem) Hardlink(target string) bool {
+	t, err := sb.target(true, target)
+	if err != nil || sb.ok(false) != nil {
+		return false
+	}
+	return sb.FileSystem.Hardlink(t)
+}
+
+// ---------------------------------------
+// 锁、文件信息 :
+
+func (sb *sandboxFileSystem) Lock() (*FileLock, error) {
+	if err := sb.ok(true); err != nil {
+		return nil, err
+	}
+	return sb.FileSystem.Lock()
+}
+
+func (sb *sandboxFileSystem) RLock() (*FileLock, error) {
+	if err := sb.ok(true); err != nil {
+		return nil, err
+	}
+	return sb.FileSystem.RLock()
+}
+
+func (sb *sandboxFileSystem) TryLock(timeout time.Duration) (*FileLock, error) {
+	if err := sb.ok(true); err != nil {
+		return nil, err
+	}
+	return sb.FileSystem.TryLock(timeout)
+}
+
+func (sb *sandboxFileSystem) WithLock(fn func() error) error {
+	if err := sb.ok(true); err != nil {
+		return err
+	}
+	return sb.FileSystem.WithLock(fn)
+}
+
+func (sb *sandboxFileSystem) Stat() (*FileStat, error) {
+	if err := sb.ok(true); err != nil {
+		return nil, err
+	}
+	return sb.FileSystem.Stat()
+}
+
+func (sb *sandboxFileSystem) Touch(t ...time.Time) bool {
+	return sb.ok(true) == nil && sb.FileSystem.Touch(t...)
+}
@@ -0,0 +1,205 @@
+package snake
+
+import (
+	"archive/zip"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// newTestSandbox 创建沙箱根目录及同级的沙箱外目录
+func newTestSandbox(t *testing.T) (*SandboxFS, string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	root := filepath.Join(dir, "root")
+	outside := filepath.Join(dir, "outside")
+	for _, p := range []string{root, outside} {
+		if err := os.MkdirAll(p, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("secret"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sb, err := Sandbox(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sb, root, outside
+}
+
+// symlinkOrSkip 创建符号链接，系统不支持时跳过测试
+func symlinkOrSkip(t *testing.T, target, p string) {
+	t.Helper()
+	if err := os.Symlink(target, p); err != nil {
+		t.Skipf("symlink not supported: %v", err)
+	}
+}
+
+func TestSandboxDotDot(t *testing.T) {
+	sb, root, outside := newTestSandbox(t)
+
+	for _, p := range []string{"..", "../outside/secret.txt", "a/../../outside", `a\..\..\outside`, filepath.Join(outside, "secret.txt")} {
+		if _, err := sb.FS(p); !errors.Is(err, ErrSandboxEscape) {
+			t.Errorf("FS(%q): got %v, want ErrSandboxEscape", p, err)
+		}
+	}
+
+	f, err := sb.FS("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	escaped := f.Add("../../outside/secret.txt")
+	if escaped.Exist() {
+		t.Errorf("Add(..).Exist() = true, want false")
+	}
+	if _, _, err := escaped.ReadText(); !errors.Is(err, ErrSandboxEscape) {
+		t.Errorf("Add(..).ReadText(): got %v, want ErrSandboxEscape", err)
+	}
+	if escaped.Rm() {
+		t.Errorf("Add(..).Rm() = true, want false")
+	}
+
+	// 越界的目标目录 ...
+	if err := os.WriteFile(filepath.Join(root, "a.txt"), []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	src, _ := sb.FS("a.txt")
+	if src.Cp("../outside", true) {
+		t.Errorf("Cp(../outside) = true, want false")
+	}
+	if _, err := os.Stat(filepath.Join(outside, "a.txt")); !os.IsNotExist(err) {
+		t.Errorf("file copied outside sandbox: %v", err)
+	}
+}
+
+func TestSandboxSymlinkEscape(t *testing.T) {
+	sb, root, outside := newTestSandbox(t)
+	symlinkOrSkip(t, outside, filepath.Join(root, "dir"))
+	symlinkOrSkip(t, filepath.Join(outside, "secret.txt"), filepath.Join(root, "file"))
+	symlinkOrSkip(t, filepath.Join(outside, "new.txt"), filepath.Join(root, "dangling"))
+
+	for _, p := range []string{"dir", "dir/secret.txt", "file", "dangling"} {
+		if _, err := sb.FS(p); !errors.Is(err, ErrSandboxEscape) {
+			t.Errorf("FS(%q): got %v, want ErrSandboxEscape", p, err)
+		}
+	}
+
+	// 通过链接写入沙箱外 ...
+	f, err := sb.FS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Add("dangling").WriteText("x", "UTF-8"); !errors.Is(err, ErrSandboxEscape) {
+		t.Errorf("WriteText through dangling link: got %v, want ErrSandboxEscape", err)
+	}
+	if _, err := os.Stat(filepath.Join(outside, "new.txt")); !os.IsNotExist(err) {
+		t.Errorf("file created outside sandbox: %v", err)
+	}
+
+	// 删除链接时只删除链接本身 ...
+	if !f.Rm("file") {
+		t.Errorf("Rm(file) = false, want true")
+	}
+	if _, err := os.Stat(filepath.Join(outside, "secret.txt")); err != nil {
+		t.Errorf("link target removed: %v", err)
+	}
+}
+
+// writeTestZip 创建包含指定条目的zip文件
+func writeTestZip(t *testing.T, p string, names ...string) {
+	t.Helper()
+	f, err := os.Create(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	w := zip.NewWriter(f)
+	for _, name := range names {
+		e, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := e.Write([]byte("evil")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSandboxUnzipSlip(t *testing.T) {
+	sb, root, outside := newTestSandbox(t)
+
+	writeTestZip(t, filepath.Join(root, "slip.zip"), "ok.txt", "../../outside/evil.txt")
+	f, _ := sb.FS("slip.zip")
+	if _, err := f.Unzip(); err == nil {
+		t.Errorf("Unzip with ../ entry: got nil error")
+	}
+	if _, err := os.Stat(filepath.Join(outside, "evil.txt")); !os.IsNotExist(err) {
+		t.Errorf("zip entry written outside sandbox: %v", err)
+	}
+
+	// 解压目录中已有指向沙箱外的符号链接 ...
+	if err := os.MkdirAll(filepath.Join(root, "link"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	symlinkOrSkip(t, outside, filepath.Join(root, "link", "out"))
+	writeTestZip(t, filepath.Join(root, "link.zip"), "out/evil.txt")
+	f, _ = sb.FS("link.zip")
+	if _, err := f.Unzip(); !errors.Is(err, ErrSandboxEscape) {
+		t.Errorf("Unzip through symlink: got %v, want ErrSandboxEscape", err)
+	}
+	if _, err := os.Stat(filepath.Join(outside, "evil.txt")); !os.IsNotExist(err) {
+		t.Errorf("zip entry written through symlink: %v", err)
+	}
+}
+
+func TestSandboxOptionsNotModified(t *testing.T) {
+	sb, root, _ := newTestSandbox(t)
+	if err := os.WriteFile(filepath.Join(root, "defaults.yml"), []byte("name: a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "config.yml"), []byte("port: 80\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var conf struct {
+		Name string
+		Port int
+	}
+	f, _ := sb.FS("config.yml")
+	copts := []ConfigOptions{{Defaults: "defaults.yml"}}
+	for i := 0; i < 2; i++ {
+		if _, err := f.LoadConfig(&conf, copts...); err != nil {
+			t.Fatalf("LoadConfig #%d: %v", i, err)
+		}
+	}
+	if copts[0].Defaults != "defaults.yml" {
+		t.Errorf("LoadConfig modified opts.Defaults: %q", copts[0].Defaults)
+	}
+
+	gbk, err := String("织梦").ToCharset("GBK")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "a.txt"), []byte(gbk), 0644); err != nil {
+		t.Fatal(err)
+	}
+	f, _ = sb.FS("a.txt")
+	topts := []TranscodeOptions{{BackupDir: "bak"}}
+	for i := 0; i < 2; i++ {
+		if _, err := f.Transcode("GBK", "UTF-8", topts...); err != nil {
+			t.Fatalf("Transcode #%d: %v", i, err)
+		}
+	}
+	if topts[0].BackupDir != "bak" {
+		t.Errorf("Transcode modified opts.BackupDir: %q", topts[0].BackupDir)
+	}
+	if _, err := os.Stat(filepath.Join(root, "bak", "a.txt")); err != nil {
+		t.Errorf("backup not written inside sandbox: %v", err)
+	}
+}
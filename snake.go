@@ -1,6 +1,7 @@
 package snake
 
-var mimeTypes = map[string]string{
+// builtinMimeTypes 内置扩展名与MimeTypes对照表，作为默认MimeRegistry的初始数据
+var builtinMimeTypes = map[string]string{
 	"123":                      "application/vnd.lotus-1-2-3",
 	"3dml":                     "text/vnd.in3d.3dml",
 	"3ds":                      "image/x-3ds",
@@ -0,0 +1,136 @@
+package snake
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// FileStat 文件信息
+type FileStat struct {
+	Name       string      // 文件名
+	Size       int64       // 文件大小
+	Blocks     int64       // 实际占用磁盘空间（字节），系统不支持时等于Size
+	Mode       os.FileMode // 权限及类型
+	ModTime    time.Time   // 修改时间
+	AccessTime time.Time   // 访问时间，系统不支持时等于ModTime
+	ChangeTime time.Time   // 状态变化时间，系统不支持时等于ModTime
+	Uid        int         // 用户ID，系统不支持时为-1
+	Gid        int         // 用户组ID，系统不支持时为-1
+	Inode      uint64      // inode编号，系统不支持时为0
+	Nlink      uint64      // 硬链接数量，系统不支持时为1
+	Dev        uint64      // 设备编号，系统不支持时为0
+}
+
+// IsDir 是否为目录
+func (s *FileStat) IsDir() bool {
+	return s.Mode.IsDir()
+}
+
+// IsSymlink 是否为符号链接
+func (s *FileStat) IsSymlink() bool {
+	return s.Mode&os.ModeSymlink != 0
+}
+
+// DiskUsage 目录占用空间统计
+type DiskUsage struct {
+	Path     string       // 路径
+	Size     int64        // 文件大小合计
+	Blocks   int64        // 实际占用磁盘空间合计
+	Files    int          // 文件数量
+	Dirs     int          // 子目录数量
+	Children []*DiskUsage // 子目录统计，按路径排序
+}
+
+// Stat 获取文件信息，符号链接返回链接指向的文件信息
+func (sk *snakeFileSystem) Stat() (*FileStat, error) {
+	info, err := os.Stat(sk.Path)
+	if err != nil {
+		return nil, err
+	}
+	return newFileStat(info), nil
+}
+
+// Touch 修改文件的访问及修改时间，文件不存在时创建空文件，t为空时使用当前时间
+func (sk *snakeFileSystem) Touch(t ...time.Time) bool {
+	now := time.Now()
+	if len(t) > 0 {
+		now = t[0]
+	}
+
+	if !sk.Exist() {
+		f, ok := sk.MkFile()
+		if !ok {
+			return false
+		}
+		f.Close()
+	}
+	return os.Chtimes(sk.Path, now, now) == nil
+}
+
+// Du 统计目录占用空间，与du命令一致，硬链接只统计一次，不跟随符号链接
+// 例子：
+// du, err := snake.FS("./uploads").Du()
+// for _, v := range du.Children { fmt.Println(v.Path, v.Size, v.Files) }
+func (sk *snakeFileSystem) Du() (*DiskUsage, error) {
+	info, err := os.Lstat(sk.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[[2]uint64]bool{}
+	if !info.IsDir() {
+		s := newFileStat(info)
+		return &DiskUsage{Path: sk.Get(), Size: s.Size, Blocks: s.Blocks, Files: 1}, nil
+	}
+	return du(sk.Get(), seen)
+}
+
+// du 递归统计目录
+func du(p string, seen map[[2]uint64]bool) (*DiskUsage, error) {
+	res := &DiskUsage{Path: p}
+
+	entries, err := os.ReadDir(p)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+
+		if info.IsDir() {
+			child, err := du(filepath.Join(p, entry.Name()), seen)
+			if err != nil {
+				return nil, err
+			}
+			res.Children = append(res.Children, child)
+			res.Size += child.Size
+			res.Blocks += child.Blocks
+			res.Files += child.Files
+			res.Dirs += child.Dirs + 1
+			continue
+		}
+
+		s := newFileStat(info)
+		if s.Nlink > 1 && s.Inode != 0 {
+			key := [2]uint64{s.Dev, s.Inode}
+			if seen[key] {
+				res.Files++
+				continue
+			}
+			seen[key] = true
+		}
+		res.Size += s.Size
+		res.Blocks += s.Blocks
+		res.Files++
+	}
+
+	sort.Slice(res.Children, func(i, j int) bool {
+		return res.Children[i].Path < res.Children[j].Path
+	})
+	return res, nil
+}
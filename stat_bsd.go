@@ -0,0 +1,38 @@
+//go:build darwin || freebsd
+// +build darwin freebsd
+
+package snake
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// newFileStat 根据os.FileInfo生成FileStat
+func newFileStat(info os.FileInfo) *FileStat {
+	s := &FileStat{
+		Name:       info.Name(),
+		Size:       info.Size(),
+		Blocks:     info.Size(),
+		Mode:       info.Mode(),
+		ModTime:    info.ModTime(),
+		AccessTime: info.ModTime(),
+		ChangeTime: info.ModTime(),
+		Uid:        -1,
+		Gid:        -1,
+		Nlink:      1,
+	}
+
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		s.Blocks = int64(st.Blocks) * 512
+		s.AccessTime = time.Unix(int64(st.Atimespec.Sec), int64(st.Atimespec.Nsec))
+		s.ChangeTime = time.Unix(int64(st.Ctimespec.Sec), int64(st.Ctimespec.Nsec))
+		s.Uid = int(st.Uid)
+		s.Gid = int(st.Gid)
+		s.Inode = uint64(st.Ino)
+		s.Nlink = uint64(st.Nlink)
+		s.Dev = uint64(st.Dev)
+	}
+	return s
+}
@@ -0,0 +1,22 @@
+//go:build !linux && !darwin && !freebsd
+// +build !linux,!darwin,!freebsd
+
+package snake
+
+import "os"
+
+// newFileStat 根据os.FileInfo生成FileStat，当前系统只提供基本信息
+func newFileStat(info os.FileInfo) *FileStat {
+	return &FileStat{
+		Name:       info.Name(),
+		Size:       info.Size(),
+		Blocks:     info.Size(),
+		Mode:       info.Mode(),
+		ModTime:    info.ModTime(),
+		AccessTime: info.ModTime(),
+		ChangeTime: info.ModTime(),
+		Uid:        -1,
+		Gid:        -1,
+		Nlink:      1,
+	}
+}
@@ -0,0 +1,262 @@
+package snake
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/text/transform"
+)
+
+// sniffSize 自动检测编码时读取的开头字节数
+const sniffSize = 64 * 1024
+
+// sniffMaxASCII 自动检测编码时最多缓存的ASCII开头字节数
+const sniffMaxASCII = 16 * 1024 * 1024
+
+// nopWriteCloser 不需要转换时的Writer
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+// NewDecodingReader 将r的内容按charset转换为UTF-8，返回转换后的Reader及源编码
+// charset为空或"auto"时读取到第一个非ASCII字节之后的一部分内容检测编码，之后的内容按检测结果流式转换，不会读取全部内容。
+// 开头超过16MB仍全部为ASCII且未读完时无法判断编码，返回 ErrUnknownCharset，此时应指定charset。
+// BOM会被去除。
+// 例子：
+// r, charset, err := snake.NewDecodingReader(f, "auto")
+func NewDecodingReader(r io.Reader, charset string) (io.Reader, string, error) {
+	charset = strings.ToUpper(charset)
+
+	if charset == "" || charset == "AUTO" {
+		read, sample, partial, err := readCharsetSample(r)
+		if err != nil {
+			return nil, "", err
+		}
+		if charset, err = detectTextCharset(sample, partial); err != nil {
+			return nil, "", err
+		}
+		r = io.MultiReader(bytes.NewReader(read), r)
+	}
+	br := bufio.NewReaderSize(r, sniffSize)
+
+	// 去除BOM ...
+	for _, v := range textBOMs {
+		if charset == v.charset || (charset == "UTF-8" && v.charset == CharsetUTF8BOM) {
+			if prefix, _ := br.Peek(len(v.bom)); string(prefix) == string(v.bom) {
+				br.Discard(len(v.bom))
+			}
+		}
+	}
+
+	switch charset {
+	case "UTF-8", "UTF8", CharsetUTF8BOM:
+		return br, charset, nil
+	}
+
+	encode := getEncoding(charset)
+	if encode == nil {
+		return nil, "", fmt.Errorf("unsupported charset: %v", charset)
+	}
+	return transform.NewReader(br, encode.NewDecoder()), charset, nil
+}
+
+// NewEncodingWriter 将写入的UTF-8内容按charset转换后写入w，charset支持 UTF-8-BOM
+// 写入完成后必须调用Close，否则末尾的内容可能不会写入，Close不会关闭w。
+// 目标编码无法表示的字符返回错误。
+func NewEncodingWriter(w io.Writer, charset string) (io.WriteCloser, error) {
+	switch strings.ToUpper(charset) {
+	case "", "UTF-8", "UTF8":
+		return nopWriteCloser{w}, nil
+	case CharsetUTF8BOM:
+		if _, err := w.Write([]byte{0xef, 0xbb, 0xbf}); err != nil {
+			return nil, err
+		}
+		return nopWriteCloser{w}, nil
+	}
+
+	encode := getEncoding(charset)
+	if encode == nil {
+		return nil, fmt.Errorf("unsupported charset: %v", charset)
+	}
+	return transform.NewWriter(w, encode.NewEncoder()), nil
+}
+
+// transcodeStream 流式转换单个文件，不读取全部内容，适用于大文件
+// 备份时直接将原文件重命名为备份文件。
+func (sk *snakeFileSystem) transcodeStream(p, rel, from, to string, opt TranscodeOptions) TranscodeResult {
+	r := TranscodeResult{Path: p, To: to}
+	fail := func(err error) TranscodeResult {
+		r.Status, r.Err = TranscodeFailed, err
+		return r
+	}
+
+	f, err := os.Open(p)
+	if err != nil {
+		return fail(err)
+	}
+	defer f.Close()
+
+	prefix := make([]byte, sniffSize)
+	n, err := f.ReadAt(prefix, 0)
+	if err != nil && err != io.EOF {
+		return fail(err)
+	}
+	if !isText(trimPartialRune(prefix[:n])) {
+		r.Status = TranscodeBinary
+		return r
+	}
+
+	// 与目录转换一致，先检测实际编码：与源编码不符、已是目标编码或纯ASCII时跳过 ...
+	sample, ascii, err := sniffCharsetSample(f)
+	if err != nil {
+		return fail(err)
+	}
+	charset := "UTF-8"
+	if !ascii {
+		if charset, err = detectTextCharset(sample, len(sample) == sniffSize); err != nil {
+			return fail(err)
+		}
+	}
+	r.From = charset
+	already := sameCharset(charset, to) || (ascii && to != CharsetUTF8BOM)
+	if already || (from != "" && !sameCharset(charset, from)) {
+		r.Status = TranscodeSkipped
+		return r
+	}
+
+	src, _, err := NewDecodingReader(f, charset)
+	if err != nil {
+		return fail(err)
+	}
+
+	if !opt.NoBackup {
+		r.Backup = p + opt.BackupSuffix
+		if opt.BackupDir != "" {
+			r.Backup = filepath.Join(opt.BackupDir, rel)
+		}
+		if err := checkBackup(r.Backup); err != nil {
+			return fail(err)
+		}
+	}
+	if opt.DryRun {
+		r.Status = TranscodeConverted
+		return r
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return fail(err)
+	}
+	tmp, _, err := TempFile("."+filepath.Base(p)+".*", filepath.Dir(p))
+	if err != nil {
+		return fail(err)
+	}
+	defer os.Remove(tmp.Get())
+
+	out, err := os.OpenFile(tmp.Get(), os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return fail(err)
+	}
+	w, err := NewEncodingWriter(out, to)
+	if err == nil {
+		_, err = io.Copy(w, src)
+		if cerr := w.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Get(), info.Mode().Perm())
+	}
+	if err != nil {
+		return fail(err)
+	}
+
+	if r.Backup != "" {
+		if err := os.MkdirAll(FS(r.Backup).Dir(), os.ModePerm); err != nil {
+			return fail(err)
+		}
+		if err := moveTree(p, r.Backup); err != nil {
+			return fail(err)
+		}
+	}
+	if err := os.Rename(tmp.Get(), p); err != nil {
+		return fail(err)
+	}
+	r.Status = TranscodeConverted
+	return r
+}
+
+// sniffCharsetSample 读取用于检测编码的内容，开头为纯ASCII时从第一个非ASCII字节处读取
+// 全部内容为ASCII时ascii为true。
+func sniffCharsetSample(f *os.File) ([]byte, bool, error) {
+	buf := make([]byte, sniffSize)
+	for off := int64(0); ; off += sniffSize {
+		n, err := f.ReadAt(buf, off)
+		if err != nil && err != io.EOF {
+			return nil, false, err
+		}
+		for i, b := range buf[:n] {
+			if b < 0x80 {
+				continue
+			}
+			if off == 0 {
+				return buf[:n], false, nil
+			}
+			n, err := f.ReadAt(buf, off+int64(i))
+			if err != nil && err != io.EOF {
+				return nil, false, err
+			}
+			return buf[:n], false, nil
+		}
+		if err == io.EOF || n < sniffSize {
+			return nil, true, nil
+		}
+	}
+}
+
+// readCharsetSample 从r读取检测编码用的内容，返回已读取的全部内容及用于检测的部分
+// 开头的ASCII内容在所有兼容ASCII的编码中都相同，无法用于判断编码，检测内容从第一个非ASCII字节或零字节附近开始，
+// 零字节附近按4字节对齐，以便判断UTF-16、UTF-32。partial为false时已读完全部内容。
+func readCharsetSample(r io.Reader) ([]byte, []byte, bool, error) {
+	var read []byte
+	buf := make([]byte, 32*1024)
+	start := -1
+	for {
+		if start < 0 && len(read) > sniffMaxASCII {
+			return nil, nil, false, ErrUnknownCharset
+		}
+		if start >= 0 && len(read)-start >= sniffSize {
+			return read, read[start : start+sniffSize], true, nil
+		}
+
+		n, err := r.Read(buf)
+		if start < 0 {
+			for i, b := range buf[:n] {
+				if b >= 0x80 || b == 0 {
+					start = (len(read) + i) &^ 3
+					break
+				}
+			}
+		}
+		read = append(read, buf[:n]...)
+		if err == io.EOF {
+			if start < 0 {
+				return read, read, false, nil
+			}
+			return read, read[start:], false, nil
+		}
+		if err != nil {
+			return nil, nil, false, err
+		}
+	}
+}
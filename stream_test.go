@@ -0,0 +1,32 @@
+package snake
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestDecodingReaderLongASCIIPrefix(t *testing.T) {
+	// 开头的ASCII内容超过 sniffSize 的GBK文件，例如SQL导出文件 ...
+	header := strings.Repeat("-- dedecms sql dump\n", 75*1024/20)
+	gbk, err := String("织梦内容管理系统，欢迎使用！").ToCharset("GBK")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, charset, err := NewDecodingReader(bytes.NewReader([]byte(header+gbk)), "auto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sameCharset(charset, "GBK") {
+		t.Errorf("charset: got %q, want GBK", charset)
+	}
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != header+"织梦内容管理系统，欢迎使用！" {
+		t.Errorf("decoded tail: %q", out[len(header):])
+	}
+}
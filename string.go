@@ -5,7 +5,6 @@ import (
 	"crypto/md5"
 	"fmt"
 	"html"
-	"io/ioutil"
 	"net/url"
 	"regexp"
 	"strconv"
@@ -13,8 +12,6 @@ import (
 	"unicode"
 
 	"github.com/dedecms/snake/pkg"
-	"github.com/yuin/charsetutil"
-	"golang.org/x/text/transform"
 	"golang.org/x/text/width"
 )
 
@@ -447,34 +444,13 @@ func (t *SnakeString) Unescape() string {
 // 字符集 :
 
 // Charset Function
-// 返回当前进程的字符集 ...
+// 返回当前进程的字符集，即 DetectCharset 中可信度最高的编码 ...
 func (t *SnakeString) Charset() (string, bool) {
-
-	// 自动获取编码 ...
-	encoding, err := charsetutil.GuessBytes(t.Byte())
-
-	// 如果自动获取成功或encoding不为空
-	// 则输出编码格式 ...
-	if err == nil {
-		return strings.ToUpper(encoding.Charset()), true
-	}
-
-	if t.IsGBK() {
-		return "GBK", true
-	}
-
-	if encoding != nil && encoding.Charset() != "WINDOWS-1252" {
-		return strings.ToUpper(encoding.Charset()), true
+	if c := t.DetectCharset(); len(c) > 0 {
+		return c[0].Charset, true
 	}
 
-	// 如果内容中出现汉字
-	// 则输出GB18030 ...
-	if t.ExistHan() {
-		return "GBK", true
-	}
-
-	// 不符合上述条件
-	// 则返回空 ...
+	// 无法识别编码 ...
 	return "", false
 }
 
@@ -491,28 +467,9 @@ func (t *SnakeString) ExistHan() bool {
 }
 
 // ExistGBK Function
-// 判断是否为GBK ...
+// 判断是否为GBK，逐个验证双字节序列的首字节与尾字节范围 ...
 func (t *SnakeString) IsGBK() bool {
-	arr := t.Byte()
-	var i int = 0
-	for i < len(t.Byte()) {
-		if arr[i] <= 0xff {
-			i++
-			continue
-		} else {
-			if arr[i] >= 0x81 &&
-				arr[i] <= 0xfe &&
-				arr[i+1] >= 0x40 &&
-				arr[i+1] <= 0xfe &&
-				arr[i+1] != 0xf7 {
-				i += 2
-				continue
-			} else {
-				return false
-			}
-		}
-	}
-	return true
+	return isGBKBytes(t.Byte())
 }
 
 // 判断是否为UTF8
@@ -541,29 +498,50 @@ func (t *SnakeString) IsUTF8() bool {
 // 运行对当前进程进行编码转换成UTF-8 ...
 func (t *SnakeString) ToUTF8() (string, bool) {
 
-	// 自动获取资源编码 ...
-	charset, ok := t.Charset()
+	// 自动获取资源编码并转换，去除BOM ...
+	src, charset, err := decodeText(t.Byte())
 
-	// 未获取到资源编码 ...
-	if !ok {
+	// 未获取到资源编码或转码失败 ...
+	if err != nil {
 		return t.Input, false
 	}
 
 	// UTF-8无需转换 ...
-	if charset == "UTF-8" {
+	if charset == "UTF-8" || charset == CharsetUTF8BOM {
+		t.Input = src
 		return t.Input, true
 	}
 
-	if encode := getEncoding(charset); encode != nil {
-		if reader, err := ioutil.ReadAll(transform.NewReader(bytes.NewReader(t.Byte()), encode.NewDecoder())); err == nil {
-			t.Input = string(reader)
-			t.Input = html.UnescapeString(t.Input)
-			return t.Input, true
-		}
+	t.Input = html.UnescapeString(src)
+	return t.Input, true
+}
+
+// ToCharset Function
+// 将UTF-8内容转换为指定编码，mode为目标编码无法表示的字符的处理方式，默认返回错误 ...
+// 例子：
+// s, err := snake.String("织梦😀").ToCharset("GBK", snake.UnencodableEntity)
+func (t *SnakeString) ToCharset(charset string, mode ...UnencodableMode) (string, error) {
+	m := UnencodableError
+	if len(mode) > 0 {
+		m = mode[0]
+	}
+	res, err := encodeTextWith(t.Input, charset, m)
+	if err != nil {
+		return t.Input, err
 	}
+	t.Input = string(res)
+	return t.Input, nil
+}
 
-	// 转码失败
-	return t.Input, false
+// FromCharset Function
+// 将指定编码的内容转换为UTF-8，不做编码检测 ...
+func (t *SnakeString) FromCharset(charset string) (string, error) {
+	res, err := decodeTextAs(t.Byte(), strings.ToUpper(charset))
+	if err != nil {
+		return t.Input, err
+	}
+	t.Input = res
+	return t.Input, nil
 }
 
 // LCFirst 字符串首字母小写 ...
@@ -4,6 +4,7 @@ import (
 	"archive/tar"
 	"bytes"
 	"io/fs"
+	"os"
 	"path/filepath"
 
 	"github.com/dsnet/compress/bzip2"
@@ -28,16 +29,22 @@ func Tar(tarfile string) *Tarlib {
 }
 
 func (t *Tarlib) Add(path string, stat fs.FileInfo, body []byte) bool {
-	if !String(path).Find(".DS_Store", true) && !String(path).Find("__MACOSX", true) && !String(path).Find(".gitignore", true) && !String(path).Find(".index", true) {
-		header, _ := tar.FileInfoHeader(stat, path)
-		header.Name = filepath.ToSlash(path)
-		header.Size = int64(len(body))
-		if err := t.FS.WriteHeader(header); err == nil {
-			_, err := t.FS.Write(body)
-			return err == nil
-		}
+	return !archiveIgnored(path) && t.add(path, stat, body) == nil
+}
+
+// add 写入一个条目
+func (t *Tarlib) add(path string, stat fs.FileInfo, body []byte) error {
+	header, err := tar.FileInfoHeader(stat, path)
+	if err != nil {
+		return err
 	}
-	return false
+	header.Name = filepath.ToSlash(path)
+	header.Size = int64(len(body))
+	if err := t.FS.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err = t.FS.Write(body)
+	return err
 }
 
 func (t *Tarlib) Close() error {
@@ -46,3 +53,45 @@ func (t *Tarlib) Close() error {
 	_, err := FS(t.FileName).ByteWriter(t.Buffer.Bytes())
 	return err
 }
+
+// AddDir 将目录添加到压缩包，路径以目录名开头
+// 符号链接的处理方式由 root.Links() 设置：默认写入链接本身，LinkFollow 时写入链接指向的内容。
+// 与 Add 相同跳过 .DS_Store、__MACOSX 等文件，任一条目写入失败时返回错误。
+func (t *Tarlib) AddDir(root FileSystem) error {
+	base := root.Dir()
+	return walkTree(root.Get(), root.LinkPolicy(), func(p string, info fs.FileInfo) error {
+		rel, err := filepath.Rel(base, p)
+		if err != nil {
+			return err
+		}
+		if archiveIgnored(rel) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		switch {
+		case info.Mode()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(p)
+			if err != nil {
+				return err
+			}
+			header, err := tar.FileInfoHeader(info, link)
+			if err != nil {
+				return err
+			}
+			header.Name = filepath.ToSlash(rel)
+			return t.FS.WriteHeader(header)
+		case info.IsDir():
+			return t.add(rel, info, nil)
+		case info.Mode().IsRegular():
+			body, err := os.ReadFile(p)
+			if err != nil {
+				return err
+			}
+			return t.add(rel, info, body)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,138 @@
+package snake
+
+import (
+	"os"
+	"sync"
+)
+
+// tempRoot 默认的临时目录位置，为空时使用系统临时目录
+var tempRoot struct {
+	sync.RWMutex
+	dir string
+}
+
+// SetTempRoot 设置默认的临时目录位置，为空时恢复使用系统临时目录
+// 需要通过os.Rename原子替换文件时，应将临时目录设置在目标文件所在的设备上。
+func SetTempRoot(dir string) {
+	tempRoot.Lock()
+	defer tempRoot.Unlock()
+	tempRoot.dir = dir
+}
+
+// TempRoot 返回默认的临时目录位置
+func TempRoot() string {
+	tempRoot.RLock()
+	defer tempRoot.RUnlock()
+	if tempRoot.dir != "" {
+		return tempRoot.dir
+	}
+	return os.TempDir()
+}
+
+// TempDir 创建临时目录，返回目录及清理函数
+// pattern规则与os.MkdirTemp一致，"*"会被替换为随机字符；dir为空时使用TempRoot()。
+// 例子：
+// tmp, cleanup, err := snake.TempDir("build-*")
+// defer cleanup()
+func TempDir(pattern string, dir ...string) (FileSystem, func(), error) {
+	root := tempDirRoot(dir...)
+	if !FS(root).Exist() {
+		FS(root).MkDir()
+	}
+
+	p, err := os.MkdirTemp(root, pattern)
+	if err != nil {
+		return nil, func() {}, err
+	}
+	return FS(p), func() { os.RemoveAll(p) }, nil
+}
+
+// TempFile 创建临时文件，返回文件及清理函数
+// 例子：
+// tmp, cleanup, err := snake.TempFile("cache-*.tmp", snake.FS("data/cache").Get())
+// defer cleanup()
+func TempFile(pattern string, dir ...string) (FileSystem, func(), error) {
+	root := tempDirRoot(dir...)
+	if !FS(root).Exist() {
+		FS(root).MkDir()
+	}
+
+	f, err := os.CreateTemp(root, pattern)
+	if err != nil {
+		return nil, func() {}, err
+	}
+	p := f.Name()
+	f.Close()
+	return FS(p), func() { os.Remove(p) }, nil
+}
+
+// TempScope 临时文件作用域，Cleanup时删除作用域内创建的全部临时文件及目录
+type TempScope struct {
+	mu    sync.Mutex
+	dir   string
+	paths []string
+}
+
+// NewTempScope 新建临时文件作用域，dir为空时使用TempRoot()
+func NewTempScope(dir ...string) *TempScope {
+	return &TempScope{dir: tempDirRoot(dir...)}
+}
+
+// WithTemp 在临时文件作用域中执行fn，结束时（包括panic）删除作用域内创建的全部临时文件及目录
+// 例子：
+// err := snake.WithTemp(func(s *snake.TempScope) error { tmp, err := s.Dir("unzip-*"); ... })
+func WithTemp(fn func(s *TempScope) error, dir ...string) error {
+	s := NewTempScope(dir...)
+	defer s.Cleanup()
+	return fn(s)
+}
+
+// Dir 在作用域内创建临时目录
+func (s *TempScope) Dir(pattern string) (FileSystem, error) {
+	p, _, err := TempDir(pattern, s.dir)
+	if err != nil {
+		return nil, err
+	}
+	return s.Track(p), nil
+}
+
+// File 在作用域内创建临时文件
+func (s *TempScope) File(pattern string) (FileSystem, error) {
+	p, _, err := TempFile(pattern, s.dir)
+	if err != nil {
+		return nil, err
+	}
+	return s.Track(p), nil
+}
+
+// Track 将其它位置创建的文件或目录加入作用域，Cleanup时一并删除
+func (s *TempScope) Track(fs FileSystem) FileSystem {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.paths = append(s.paths, fs.Get())
+	return fs
+}
+
+// Cleanup 删除作用域内创建的全部临时文件及目录，按创建的相反顺序删除
+func (s *TempScope) Cleanup() error {
+	s.mu.Lock()
+	paths := s.paths
+	s.paths = nil
+	s.mu.Unlock()
+
+	var res error
+	for i := len(paths) - 1; i >= 0; i-- {
+		if err := os.RemoveAll(paths[i]); err != nil && res == nil {
+			res = err
+		}
+	}
+	return res
+}
+
+// tempDirRoot 返回临时目录位置
+func tempDirRoot(dir ...string) string {
+	if len(dir) > 0 && dir[0] != "" {
+		return dir[0]
+	}
+	return TempRoot()
+}
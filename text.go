@@ -0,0 +1,211 @@
+package snake
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/transform"
+)
+
+// CharsetUTF8BOM 带BOM的UTF-8，用于 ReadText 返回及 WriteText 写入
+const CharsetUTF8BOM = "UTF-8-BOM"
+
+// ErrUnknownCharset 无法识别文件编码
+var ErrUnknownCharset = errors.New("unknown charset")
+
+// UnencodableMode 目标编码无法表示的字符的处理方式
+type UnencodableMode int
+
+const (
+	UnencodableError   UnencodableMode = iota // 返回错误
+	UnencodableReplace                        // 替换为"?"
+	UnencodableEntity                         // 替换为HTML数字实体，例如：&#128512;
+)
+
+// textBOMs 字节顺序标记
+var textBOMs = []struct {
+	charset string
+	bom     []byte
+}{
+	{CharsetUTF8BOM, []byte{0xef, 0xbb, 0xbf}},
+	{"UTF-32LE", []byte{0xff, 0xfe, 0x00, 0x00}},
+	{"UTF-32BE", []byte{0x00, 0x00, 0xfe, 0xff}},
+	{"UTF-16LE", []byte{0xff, 0xfe}},
+	{"UTF-16BE", []byte{0xfe, 0xff}},
+}
+
+// TextOptions 读取文本参数
+type TextOptions struct {
+	Charset  string // 指定源编码，为空时自动检测
+	Fallback string // 无法检测编码时使用的编码，为空时返回 ErrUnknownCharset
+}
+
+// ReadText 读取文本文件并转换为UTF-8，返回内容及检测到的编码
+// 自动去除BOM，带BOM的UTF-8返回 CharsetUTF8BOM，可原样传给 WriteText 写回。
+// 例子：
+// s, charset, err := snake.FS("templets/default/index.htm").ReadText()
+func (sk *snakeFileSystem) ReadText(opts ...TextOptions) (*SnakeString, string, error) {
+	opt := TextOptions{}
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+
+	data, err := os.ReadFile(sk.Path)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var src, charset string
+	if opt.Charset != "" {
+		charset = strings.ToUpper(opt.Charset)
+		src, err = decodeTextAs(data, charset)
+	} else {
+		src, charset, err = decodeText(data)
+		if err == ErrUnknownCharset && opt.Fallback != "" {
+			charset = strings.ToUpper(opt.Fallback)
+			src, err = decodeTextAs(data, charset)
+		}
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return String(src), charset, nil
+}
+
+// WriteText 将UTF-8文本按指定编码写入文件
+// charset支持 GBK、GB18030、Big5、UTF-8、UTF-8-BOM 等IANA编码名称，为空时为UTF-8。
+// 例子：
+// snake.FS("templets/default/index.htm").WriteText(s.Get(), charset)
+func (sk *snakeFileSystem) WriteText(s string, charset string) error {
+	data, err := encodeText(s, charset)
+	if err != nil {
+		return err
+	}
+	_, err = sk.ByteWriter(data)
+	return err
+}
+
+// ---------------------------------------
+// 辅助函数 :
+
+// decodeText 检测编码并转换为UTF-8，返回原编码
+func decodeText(data []byte) (string, string, error) {
+	charset, err := detectTextCharset(data, false)
+	if err != nil {
+		return "", "", err
+	}
+	src, err := decodeTextAs(data, charset)
+	return src, charset, err
+}
+
+// detectTextCharset 检测内容编码，partial为true时data为截取的开头部分，忽略末尾不完整的字符
+func detectTextCharset(data []byte, partial bool) (string, error) {
+	for _, v := range textBOMs {
+		if bytes.HasPrefix(data, v.bom) {
+			return v.charset, nil
+		}
+	}
+
+	c := DetectCharset(data, DetectOptions{Partial: partial})
+	if len(c) == 0 {
+		return "", ErrUnknownCharset
+	}
+	return c[0].Charset, nil
+}
+
+// trimPartialRune 去除末尾不完整的多字节字符
+func trimPartialRune(data []byte) []byte {
+	// UTF-8 ...
+	for i := 1; i <= utf8.UTFMax && i <= len(data); i++ {
+		c := data[len(data)-i]
+		if c < 0x80 {
+			break
+		}
+		if utf8.RuneStart(c) {
+			if !utf8.FullRune(data[len(data)-i:]) {
+				return data[:len(data)-i]
+			}
+			break
+		}
+	}
+
+	// GBK等双字节编码，末尾为单独的首字节 ...
+	n := 0
+	for i := len(data) - 1; i >= 0 && data[i] >= 0x80; i-- {
+		n++
+	}
+	if n%2 == 1 && !utf8.Valid(data) {
+		return data[:len(data)-1]
+	}
+	return data
+}
+
+// decodeTextAs 按指定编码转换为UTF-8，去除BOM
+func decodeTextAs(data []byte, charset string) (string, error) {
+	for _, v := range textBOMs {
+		if bytes.HasPrefix(data, v.bom) && (charset == v.charset || charset == "UTF-8" && v.charset == CharsetUTF8BOM) {
+			data = data[len(v.bom):]
+			break
+		}
+	}
+
+	switch charset {
+	case "UTF-8", CharsetUTF8BOM:
+		return string(data), nil
+	}
+
+	encode := getEncoding(charset)
+	if encode == nil {
+		return "", fmt.Errorf("unsupported charset: %v", charset)
+	}
+	res, err := ioutil.ReadAll(transform.NewReader(bytes.NewReader(data), encode.NewDecoder()))
+	if err != nil {
+		return "", err
+	}
+	return string(res), nil
+}
+
+// encodeText 将UTF-8文本转换为指定编码，目标编码无法表示的字符返回错误
+func encodeText(src, charset string) ([]byte, error) {
+	return encodeTextWith(src, charset, UnencodableError)
+}
+
+// encodeTextWith 将UTF-8文本转换为指定编码，mode为目标编码无法表示的字符的处理方式
+func encodeTextWith(src, charset string, mode UnencodableMode) ([]byte, error) {
+	switch strings.ToUpper(charset) {
+	case "", "UTF-8", "UTF8":
+		return []byte(src), nil
+	case CharsetUTF8BOM:
+		return append([]byte{0xef, 0xbb, 0xbf}, src...), nil
+	}
+
+	encode := getEncoding(charset)
+	if encode == nil {
+		return nil, fmt.Errorf("unsupported charset: %v", charset)
+	}
+	res, err := encode.NewEncoder().Bytes([]byte(src))
+	if err == nil || mode == UnencodableError {
+		return res, err
+	}
+
+	// 逐个字符转换，替换无法表示的字符 ...
+	var buf bytes.Buffer
+	enc := encode.NewEncoder()
+	for _, r := range src {
+		b, err := enc.Bytes([]byte(string(r)))
+		if err != nil {
+			if mode == UnencodableEntity {
+				b = []byte(fmt.Sprintf("&#%d;", r))
+			} else {
+				b = []byte("?")
+			}
+		}
+		buf.Write(b)
+	}
+	return buf.Bytes(), nil
+}
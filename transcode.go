@@ -0,0 +1,230 @@
+package snake
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ErrBackupExists 备份文件已存在，为避免覆盖原始备份不再转换
+var ErrBackupExists = errors.New("backup file already exists")
+
+// TranscodeStatus 单个文件的转码结果
+type TranscodeStatus string
+
+const (
+	TranscodeConverted TranscodeStatus = "converted" // 已转换
+	TranscodeSkipped   TranscodeStatus = "skipped"   // 已是目标编码或与源编码不符，跳过
+	TranscodeBinary    TranscodeStatus = "binary"    // 二进制文件，跳过
+	TranscodeFailed    TranscodeStatus = "failed"    // 转换失败
+)
+
+// defaultTranscodePatterns 默认转码的文件
+var defaultTranscodePatterns = []string{"*.htm", "*.html", "*.shtml", "*.php", "*.inc", "*.css", "*.js", "*.txt", "*.xml"}
+
+// TranscodeOptions 批量转码参数
+type TranscodeOptions struct {
+	Patterns     []string // Find规则，默认为常见模板及脚本文件
+	Ignore       []string // 忽略规则，匹配文件名或相对路径
+	DryRun       bool     // 只检测并返回结果，不修改文件
+	NoBackup     bool     // 不备份原文件
+	BackupSuffix string   // 备份文件后缀，默认".bak"
+	BackupDir    string   // 备份目录，设置后按相对路径备份到该目录，不再使用后缀，位于转换目录内时其中的文件不会被转换
+	HTML         bool     // 同时修改HTML meta、XML声明、PHP header() 及CSS @charset中声明的编码，字节检测不可靠时按声明的编码解码
+}
+
+// TranscodeResult 单个文件的转码结果
+type TranscodeResult struct {
+	Path   string          // 文件路径
+	From   string          // 检测到的编码
+	To     string          // 目标编码
+	Status TranscodeStatus // 转码结果
+	Backup string          // 备份文件路径
+	Err    error           // 失败原因
+}
+
+// Transcode 批量转换目录中文本文件的编码
+// from为源编码，为空或"auto"时转换所有检测到的编码；to为目标编码，支持 UTF-8-BOM。
+// 跳过二进制文件、符号链接及已是目标编码的文件，转换前备份原文件。
+// 设置 HTML 时同时修改文件中声明的编码，已是目标编码但声明不一致的文件也会被修改。
+// 未设置 HTML 时流式转换，不读取全部内容，适用于大文件；设置 HTML 时需要读取整个文件修改声明。
+// 当前路径为文件时只转换该文件。备份文件已存在时不会覆盖，返回 ErrBackupExists。
+// 例子：
+// res, err := snake.FS("templets").Transcode("GBK", "UTF-8", snake.TranscodeOptions{DryRun: true})
+func (sk *snakeFileSystem) Transcode(from, to string, opts ...TranscodeOptions) ([]TranscodeResult, error) {
+	opt := TranscodeOptions{}
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if len(opt.Patterns) == 0 {
+		opt.Patterns = defaultTranscodePatterns
+	}
+	if opt.BackupSuffix == "" {
+		opt.BackupSuffix = ".bak"
+	}
+	if strings.EqualFold(from, "auto") {
+		from = ""
+	}
+	to = strings.ToUpper(to)
+	if to == "" {
+		to = "UTF-8"
+	}
+
+	// 单个文件流式转换，不读取全部内容 ...
+	root := sk.Get()
+	if info, err := os.Lstat(root); err != nil {
+		return nil, err
+	} else if info.Mode().IsRegular() {
+		if opt.HTML {
+			return []TranscodeResult{sk.transcodeFile(root, sk.Base(), from, to, opt)}, nil
+		}
+		return []TranscodeResult{sk.transcodeStream(root, sk.Base(), from, to, opt)}, nil
+	}
+
+	// 备份目录位于转换目录内时跳过其中的文件，避免再次转换备份 ...
+	backupDir := ""
+	if opt.BackupDir != "" {
+		backupDir, _ = filepath.Abs(opt.BackupDir)
+	}
+
+	seen := map[string]bool{}
+	var files []string
+	for _, p := range sk.derive(root).Find(opt.Patterns...) {
+		rel, _ := filepath.Rel(root, p)
+		if seen[p] || matchPatterns(rel, opt.Ignore) || (opt.BackupDir == "" && strings.HasSuffix(p, opt.BackupSuffix)) {
+			continue
+		}
+		if abs, err := filepath.Abs(p); err == nil && backupDir != "" && pathWithin(backupDir, abs) {
+			continue
+		}
+		seen[p] = true
+		files = append(files, p)
+	}
+	sort.Strings(files)
+
+	res := make([]TranscodeResult, 0, len(files))
+	for _, p := range files {
+		info, err := os.Lstat(p)
+		if err != nil {
+			res = append(res, TranscodeResult{Path: p, To: to, Status: TranscodeFailed, Err: err})
+			continue
+		}
+		if !info.Mode().IsRegular() {
+			continue
+		}
+		rel, _ := filepath.Rel(root, p)
+		if opt.HTML {
+			res = append(res, sk.transcodeFile(p, rel, from, to, opt))
+		} else {
+			res = append(res, sk.transcodeStream(p, rel, from, to, opt))
+		}
+	}
+	return res, nil
+}
+
+// transcodeFile 转换单个文件
+func (sk *snakeFileSystem) transcodeFile(p, rel, from, to string, opt TranscodeOptions) TranscodeResult {
+	r := TranscodeResult{Path: p, To: to}
+	fail := func(err error) TranscodeResult {
+		r.Status, r.Err = TranscodeFailed, err
+		return r
+	}
+
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return fail(err)
+	}
+	if !isText(data) {
+		r.Status = TranscodeBinary
+		return r
+	}
+
+	src, charset, err := decodeText(data)
+	if err != nil {
+		return fail(err)
+	}
+	rewrite := false
+	if opt.HTML {
+		if s, declared, ok := decodeDeclaredText(data, charset); ok {
+			src, charset = s, declared
+		}
+		rewrite = charsetDeclarationMismatch(src, to)
+	}
+	r.From = charset
+
+	// 纯ASCII内容兼容所有目标编码，编码一致但声明不一致时仍需修改声明 ...
+	already := sameCharset(charset, to) || (isASCII(data) && to != CharsetUTF8BOM)
+	if (already && !rewrite) || (!already && from != "" && !sameCharset(charset, from)) {
+		r.Status = TranscodeSkipped
+		return r
+	}
+	if rewrite {
+		src = rewriteCharsetDeclarations(src, to)
+	}
+
+	out, err := encodeText(src, to)
+	if err != nil {
+		return fail(err)
+	}
+
+	if !opt.NoBackup {
+		r.Backup = p + opt.BackupSuffix
+		if opt.BackupDir != "" {
+			r.Backup = filepath.Join(opt.BackupDir, rel)
+		}
+		if err := checkBackup(r.Backup); err != nil {
+			return fail(err)
+		}
+	}
+	if opt.DryRun {
+		r.Status = TranscodeConverted
+		return r
+	}
+
+	if r.Backup != "" {
+		if err := sk.derive(r.Backup).AtomicWriter(data); err != nil {
+			return fail(err)
+		}
+	}
+	if err := sk.derive(p).AtomicWriter(out); err != nil {
+		return fail(err)
+	}
+	r.Status = TranscodeConverted
+	return r
+}
+
+// checkBackup 备份文件已存在时返回 ErrBackupExists
+func checkBackup(p string) error {
+	if _, err := os.Lstat(p); err == nil {
+		return ErrBackupExists
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// sameCharset 判断两个编码名称是否为同一编码
+// GB2312、GBK、GB18030 向下兼容，视为同一编码。
+func sameCharset(a, b string) bool {
+	norm := func(s string) string {
+		s = strings.ToUpper(strings.NewReplacer("_", "", "-", "").Replace(s))
+		switch s {
+		case "GB2312", "GBK", "GB18030", "CP936":
+			return "GB"
+		}
+		return s
+	}
+	return norm(a) == norm(b)
+}
+
+// isASCII 判断内容是否只包含ASCII字符
+func isASCII(data []byte) bool {
+	for _, b := range data {
+		if b >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,244 @@
+package snake
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// trashTimeLayout .trashinfo 中 DeletionDate 的时间格式
+const trashTimeLayout = "2006-01-02T15:04:05"
+
+// trashRoot 回收站位置，为空时使用默认位置
+var trashRoot struct {
+	sync.RWMutex
+	dir string
+}
+
+// TrashItem 回收站中的条目
+type TrashItem struct {
+	ID        string    // 回收站中的名称，用于Restore
+	Path      string    // 删除前的绝对路径
+	DeletedAt time.Time // 删除时间
+	IsDir     bool      // 是否为目录
+}
+
+// SetTrashRoot 设置回收站位置，为空时恢复默认位置
+func SetTrashRoot(dir string) {
+	trashRoot.Lock()
+	defer trashRoot.Unlock()
+	trashRoot.dir = dir
+}
+
+// TrashRoot 返回回收站位置
+// 默认与freedesktop规范一致：$XDG_DATA_HOME/Trash，未设置时为 ~/.local/share/Trash。
+func TrashRoot() string {
+	trashRoot.RLock()
+	defer trashRoot.RUnlock()
+	if trashRoot.dir != "" {
+		return trashRoot.dir
+	}
+	if data := os.Getenv("XDG_DATA_HOME"); data != "" {
+		return filepath.Join(data, "Trash")
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(home, ".local", "share", "Trash")
+	}
+	return filepath.Join(os.TempDir(), "Trash")
+}
+
+// Trash 将目录或文件移动到回收站，返回回收站中的ID
+// 回收站目录结构遵循freedesktop规范：files 目录保存文件，info 目录保存 .trashinfo 信息。
+// 例子：
+// id, err := snake.FS("uploads/2021").Trash()
+// snake.Restore(id)
+func (sk *snakeFileSystem) Trash() (string, error) {
+	src, err := filepath.Abs(sk.Get())
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Lstat(src); err != nil {
+		return "", err
+	}
+
+	root := TrashRoot()
+	files := filepath.Join(root, "files")
+	infos := filepath.Join(root, "info")
+	if err := os.MkdirAll(files, 0700); err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(infos, 0700); err != nil {
+		return "", err
+	}
+
+	// 先独占创建 .trashinfo 占用名称，重名时追加序号 ...
+	base := filepath.Base(src)
+	var id string
+	var info *os.File
+	for i := 1; ; i++ {
+		id = base
+		if i > 1 {
+			id = fmt.Sprintf("%s.%d", base, i)
+		}
+		info, err = os.OpenFile(filepath.Join(infos, id+".trashinfo"), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			if _, err := os.Lstat(filepath.Join(files, id)); err == nil {
+				info.Close()
+				os.Remove(filepath.Join(infos, id+".trashinfo"))
+				continue
+			}
+			break
+		}
+		if !os.IsExist(err) {
+			return "", err
+		}
+	}
+
+	_, err = fmt.Fprintf(info, "[Trash Info]\nPath=%s\nDeletionDate=%s\n", trashEscape(src), time.Now().Format(trashTimeLayout))
+	info.Close()
+	if err != nil {
+		os.Remove(filepath.Join(infos, id+".trashinfo"))
+		return "", err
+	}
+
+	if err := moveTree(src, filepath.Join(files, id)); err != nil {
+		os.Remove(filepath.Join(infos, id+".trashinfo"))
+		return "", err
+	}
+	return id, nil
+}
+
+// ListTrash 返回回收站中的全部条目，按删除时间排序
+func ListTrash() ([]TrashItem, error) {
+	root := TrashRoot()
+	entries, err := os.ReadDir(filepath.Join(root, "info"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var res []TrashItem
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), ".trashinfo") {
+			continue
+		}
+		id := strings.TrimSuffix(entry.Name(), ".trashinfo")
+		item, err := readTrashInfo(root, id)
+		if err != nil {
+			continue
+		}
+		res = append(res, item)
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].DeletedAt.Before(res[j].DeletedAt)
+	})
+	return res, nil
+}
+
+// Restore 将回收站中的条目恢复到原位置，dst不为空时恢复到指定位置
+// 目标位置已存在时返回错误。
+func Restore(id string, dst ...string) error {
+	root := TrashRoot()
+	item, err := readTrashInfo(root, id)
+	if err != nil {
+		return err
+	}
+
+	target := item.Path
+	if len(dst) > 0 && dst[0] != "" {
+		target = dst[0]
+	}
+	if _, err := os.Lstat(target); err == nil {
+		return &os.PathError{Op: "restore", Path: target, Err: os.ErrExist}
+	}
+	if err := os.MkdirAll(filepath.Dir(target), os.ModePerm); err != nil {
+		return err
+	}
+
+	if err := moveTree(filepath.Join(root, "files", id), target); err != nil {
+		return err
+	}
+	return os.Remove(filepath.Join(root, "info", id+".trashinfo"))
+}
+
+// EmptyTrash 永久删除回收站中删除时间超过olderThan的条目，olderThan为0时清空回收站
+func EmptyTrash(olderThan time.Duration) error {
+	items, err := ListTrash()
+	if err != nil {
+		return err
+	}
+
+	root := TrashRoot()
+	deadline := time.Now().Add(-olderThan)
+	for _, item := range items {
+		if olderThan > 0 && item.DeletedAt.After(deadline) {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(root, "files", item.ID)); err != nil {
+			return err
+		}
+		if err := os.Remove(filepath.Join(root, "info", item.ID+".trashinfo")); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// readTrashInfo 读取 .trashinfo 文件
+func readTrashInfo(root, id string) (TrashItem, error) {
+	item := TrashItem{ID: id}
+	if id == "" || strings.ContainsAny(id, `/\`) || id == "." || id == ".." {
+		return item, fmt.Errorf("invalid trash id: %q", id)
+	}
+
+	f, err := os.Open(filepath.Join(root, "info", id+".trashinfo"))
+	if err != nil {
+		return item, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "Path="):
+			p, err := url.PathUnescape(strings.TrimPrefix(line, "Path="))
+			if err != nil {
+				return item, err
+			}
+			item.Path = filepath.FromSlash(p)
+		case strings.HasPrefix(line, "DeletionDate="):
+			item.DeletedAt, _ = time.ParseInLocation(trashTimeLayout, strings.TrimPrefix(line, "DeletionDate="), time.Local)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return item, err
+	}
+	if item.Path == "" {
+		return item, errors.New("invalid trashinfo: missing Path")
+	}
+
+	if info, err := os.Lstat(filepath.Join(root, "files", id)); err == nil {
+		item.IsDir = info.IsDir()
+	}
+	return item, nil
+}
+
+// trashEscape 按freedesktop规范对路径进行URL编码，保留"/"
+func trashEscape(p string) string {
+	parts := strings.Split(filepath.ToSlash(p), "/")
+	for i, v := range parts {
+		parts[i] = url.PathEscape(v)
+	}
+	return strings.Join(parts, "/")
+}
@@ -0,0 +1,121 @@
+package snake
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// TreeOptions 目录树参数
+type TreeOptions struct {
+	ASCII  bool     // 使用ASCII字符绘制连接线，默认使用Unicode字符
+	Depth  int      // 最大显示层级，0为不限制
+	Ignore []string // 忽略规则，匹配文件名或相对路径
+	All    bool     // 显示以"."开头的隐藏文件
+	Size   bool     // 显示文件大小
+}
+
+// treeChars 目录树连接线
+type treeChars struct {
+	branch, last, pipe, space string
+}
+
+var (
+	unicodeTreeChars = treeChars{"├── ", "└── ", "│   ", "    "}
+	asciiTreeChars   = treeChars{"|-- ", "`-- ", "|   ", "    "}
+)
+
+// Tree 以tree命令的格式绘制目录树
+// 例子：
+// snake.FS("./templets").Tree(snake.TreeOptions{Depth: 2}).DrawBox(60)
+// 返回：
+// templets
+// ├── default
+// │   ├── index.htm
+// │   └── list.htm
+// └── system
+//
+// 2 directories, 2 files
+func (sk *snakeFileSystem) Tree(opts ...TreeOptions) *SnakeString {
+	opt := TreeOptions{}
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+
+	chars := unicodeTreeChars
+	if opt.ASCII {
+		chars = asciiTreeChars
+	}
+
+	res := String(sk.Base())
+	if opt.Size {
+		if info, err := os.Stat(sk.Path); err == nil && !info.IsDir() {
+			res = String("[", formatSize(info.Size()), "]  ", sk.Base())
+		}
+	}
+	res.Ln()
+
+	var dirs, files int
+	drawTree(res, sk.Get(), ".", "", 1, opt, chars, &dirs, &files)
+
+	return res.Ln().Add(fmt.Sprintf("%d directories, %d files", dirs, files))
+}
+
+// drawTree 递归绘制目录树
+func drawTree(res *SnakeString, root, rel, prefix string, depth int, opt TreeOptions, chars treeChars, dirs, files *int) {
+	if opt.Depth > 0 && depth > opt.Depth {
+		return
+	}
+
+	entries, err := os.ReadDir(filepath.Join(root, rel))
+	if err != nil {
+		return
+	}
+
+	var list []os.DirEntry
+	for _, entry := range entries {
+		crel := filepath.Join(rel, entry.Name())
+		if !opt.All && strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		if matchPatterns(crel, opt.Ignore) {
+			continue
+		}
+		list = append(list, entry)
+	}
+
+	for i, entry := range list {
+		connector, next := chars.branch, chars.pipe
+		if i == len(list)-1 {
+			connector, next = chars.last, chars.space
+		}
+
+		crel := filepath.Join(rel, entry.Name())
+		name := entry.Name()
+
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+
+		if info.Mode()&os.ModeSymlink != 0 {
+			if target, err := os.Readlink(filepath.Join(root, crel)); err == nil {
+				name += " -> " + target
+			}
+		}
+
+		if opt.Size && !info.IsDir() {
+			name = "[" + formatSize(info.Size()) + "]  " + name
+		}
+
+		res.Add(prefix, connector, name).Ln()
+
+		if info.IsDir() {
+			*dirs++
+			drawTree(res, root, crel, prefix+next, depth+1, opt, chars, dirs, files)
+		} else {
+			*files++
+		}
+	}
+}
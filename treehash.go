@@ -0,0 +1,156 @@
+package snake
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// TreeHashOptions 目录哈希参数
+type TreeHashOptions struct {
+	Ignore []string // 忽略规则，匹配文件名或相对路径，例如: ".git"、"*.log"、"cache/*"
+}
+
+// TreeHashResult 目录哈希结果
+type TreeHashResult struct {
+	Hash  string            // 根目录哈希
+	Dirs  map[string]string // 目录相对路径 => 目录哈希，根目录为 "."
+	Files map[string]string // 文件相对路径 => 内容哈希
+}
+
+// Changed 与旧的结果对比，返回发生变化的目录及文件相对路径（包含新增与删除）
+func (r *TreeHashResult) Changed(old *TreeHashResult) []string {
+	var res []string
+	if old == nil {
+		old = &TreeHashResult{}
+	}
+	res = append(res, diffHashMap(r.Dirs, old.Dirs)...)
+	res = append(res, diffHashMap(r.Files, old.Files)...)
+	sort.Strings(res)
+	return res
+}
+
+// TreeHash 计算目录的 Merkle 哈希
+// 每个目录的哈希由排序后的子项名称、权限及内容哈希组合而成，
+// 任意文件内容、名称或权限变化都会逐级改变上层目录的哈希。
+// 例子：
+// snake.FS("./templets").TreeHash(snake.TreeHashOptions{Ignore: []string{".DS_Store"}})
+func (sk *snakeFileSystem) TreeHash(opts ...TreeHashOptions) (*TreeHashResult, error) {
+	opt := TreeHashOptions{}
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+
+	res := &TreeHashResult{
+		Dirs:  map[string]string{},
+		Files: map[string]string{},
+	}
+
+	info, err := os.Lstat(sk.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !info.IsDir() {
+		h, err := hashEntry(sk.Path, info)
+		if err != nil {
+			return nil, err
+		}
+		res.Hash = h
+		res.Files[filepath.Base(sk.Path)] = h
+		return res, nil
+	}
+
+	h, err := treeHashDir(sk.Path, ".", opt, res)
+	if err != nil {
+		return nil, err
+	}
+	res.Hash = h
+	return res, nil
+}
+
+// treeHashDir 递归计算目录哈希
+func treeHashDir(root, rel string, opt TreeHashOptions, res *TreeHashResult) (string, error) {
+	entries, err := os.ReadDir(filepath.Join(root, rel))
+	if err != nil {
+		return "", err
+	}
+
+	// os.ReadDir 已按名称排序
+	hash := sha256.New()
+	for _, entry := range entries {
+		name := entry.Name()
+		crel := filepath.Join(rel, name)
+		if matchPatterns(crel, opt.Ignore) {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return "", err
+		}
+
+		var h string
+		if info.IsDir() {
+			h, err = treeHashDir(root, crel, opt, res)
+		} else {
+			h, err = hashEntry(filepath.Join(root, crel), info)
+			if err == nil {
+				res.Files[filepath.ToSlash(crel)] = h
+			}
+		}
+		if err != nil {
+			return "", err
+		}
+
+		fmt.Fprintf(hash, "%o %s %s\n", info.Mode()&(os.ModeType|os.ModePerm), name, h)
+	}
+
+	sum := hex.EncodeToString(hash.Sum(nil))
+	res.Dirs[filepath.ToSlash(rel)] = sum
+	return sum, nil
+}
+
+// hashEntry 计算单个文件的哈希，符号链接使用链接目标计算
+func hashEntry(path string, info os.FileInfo) (string, error) {
+	hash := sha256.New()
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Readlink(path)
+		if err != nil {
+			return "", err
+		}
+		io.WriteString(hash, target)
+		return hex.EncodeToString(hash.Sum(nil)), nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// diffHashMap 返回两个哈希表中值不同或仅存在于一方的键
+func diffHashMap(cur, old map[string]string) []string {
+	var res []string
+	for k, v := range cur {
+		if o, ok := old[k]; !ok || o != v {
+			res = append(res, k)
+		}
+	}
+	for k := range old {
+		if _, ok := cur[k]; !ok {
+			res = append(res, k)
+		}
+	}
+	return res
+}
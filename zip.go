@@ -4,6 +4,8 @@ import (
 	"archive/zip"
 	"bytes"
 	"io/fs"
+	"os"
+	"path/filepath"
 )
 
 type Ziplib struct {
@@ -21,20 +23,26 @@ func Zip(zipfile string) *Ziplib {
 }
 
 func (z *Ziplib) Add(path string, stat fs.FileInfo, body []byte) bool {
-	if !String(path).Find(".DS_Store", true) && !String(path).Find("__MACOSX", true) && !String(path).Find(".gitignore", true) && !String(path).Find(".index", true) {
-		header, _ := zip.FileInfoHeader(stat)
-		header.Name = path
-		if stat.IsDir() {
-			header.Name += "/"
-			z.FS.CreateHeader(header)
-		} else {
-			if file, err := z.FS.Create(path); err == nil {
-				_, err := file.Write(body)
-				return err == nil
-			}
+	return !archiveIgnored(path) && z.add(path, stat, body) == nil
+}
+
+// add 写入一个条目
+func (z *Ziplib) add(path string, stat fs.FileInfo, body []byte) error {
+	if stat.IsDir() {
+		header, err := zip.FileInfoHeader(stat)
+		if err != nil {
+			return err
 		}
+		header.Name = path + "/"
+		_, err = z.FS.CreateHeader(header)
+		return err
+	}
+	file, err := z.FS.Create(path)
+	if err != nil {
+		return err
 	}
-	return false
+	_, err = file.Write(body)
+	return err
 }
 
 func (z *Ziplib) Close() error {
@@ -44,3 +52,51 @@ func (z *Ziplib) Close() error {
 	}
 	return err
 }
+
+// AddDir 将目录添加到压缩包，路径以目录名开头
+// 符号链接的处理方式由 root.Links() 设置：默认按zip惯例写入链接本身（内容为链接目标），LinkFollow 时写入链接指向的内容。
+// 与 Add 相同跳过 .DS_Store、__MACOSX 等文件，任一条目写入失败时返回错误。
+func (z *Ziplib) AddDir(root FileSystem) error {
+	base := root.Dir()
+	return walkTree(root.Get(), root.LinkPolicy(), func(p string, info fs.FileInfo) error {
+		rel, err := filepath.Rel(base, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if archiveIgnored(rel) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		switch {
+		case info.Mode()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(p)
+			if err != nil {
+				return err
+			}
+			header, err := zip.FileInfoHeader(info)
+			if err != nil {
+				return err
+			}
+			header.Name = rel
+			w, err := z.FS.CreateHeader(header)
+			if err != nil {
+				return err
+			}
+			_, err = w.Write([]byte(filepath.ToSlash(link)))
+			return err
+		case info.IsDir():
+			return z.add(rel, info, nil)
+		case info.Mode().IsRegular():
+			body, err := os.ReadFile(p)
+			if err != nil {
+				return err
+			}
+			return z.add(rel, info, body)
+		}
+		return nil
+	})
+}